@@ -0,0 +1,13 @@
+// Package assets holds the base assets built into the game.
+package assets
+
+import (
+	"embed"
+)
+
+var (
+	// FS holds the manifest and every asset it lists, keyed by path relative to this directory.
+	//
+	//go:embed manifest.json data/*.json images/*.png images/*.json fonts/*.ttf
+	FS embed.FS
+)
@@ -1,10 +0,0 @@
-package fonts
-
-import (
-	_ "embed"
-)
-
-var (
-	//go:embed VT323-Regular.ttf
-	VT323Regular []byte
-)
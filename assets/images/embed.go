@@ -1,10 +0,0 @@
-package images
-
-import (
-	_ "embed"
-)
-
-var (
-	//go:embed Wizard_Sheet.png
-	WizardSheet []byte
-)
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"dungeon/internal/animation"
+	"dungeon/internal/assets"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"slices"
+)
+
+// assetpack writes every asset listed in a directory's manifest into a single pack file. Packs built from the base
+// assets replace the loose files, and packs built from a mod's directory can be loaded over them to override or add
+// assets.
+
+var (
+	srcFlag = flag.String("src", "assets", "Directory holding the manifest and the assets it lists")
+	outFlag = flag.String("o", "dungeon.pak", "Pack file to write")
+)
+
+func main() {
+	flag.Parse()
+
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	src := os.DirFS(*srcFlag)
+
+	manifest, err := assets.ReadManifest(src)
+	if err != nil {
+		return err
+	}
+	if err := manifest.Validate(src); err != nil {
+		return err
+	}
+
+	files, err := packFiles(src, manifest)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(*outFlag)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if err := assets.WritePack(out, src, files); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Packed %d assets in %d files into %s\n", len(manifest), len(files), *outFlag)
+	return nil
+}
+
+// packFiles returns the manifest and every file its assets need, including the images of sprite sheets.
+func packFiles(src fs.FS, manifest assets.Manifest) ([]string, error) {
+	files := []string{assets.ManifestFile}
+	for _, name := range manifest.Names() {
+		entry := manifest[name]
+		files = append(files, entry.Path)
+
+		if entry.Type != assets.SheetType {
+			continue
+		}
+
+		data, err := fs.ReadFile(src, entry.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		_, image, err := animation.ParseAseprite(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		files = append(files, path.Join(path.Dir(entry.Path), image))
+	}
+
+	slices.Sort(files)
+	return slices.Compact(files), nil
+}
@@ -1,15 +1,32 @@
 package main
 
 import (
+	builtin "dungeon/assets"
+	"dungeon/internal/assets"
 	"dungeon/internal/game"
 	"dungeon/internal/gfx"
-	"dungeon/internal/numerics"
+	"dungeon/internal/input"
+	"dungeon/internal/replay"
 
+	"errors"
+	"flag"
 	"github.com/hajimehoshi/ebiten/v2"
 	"go.uber.org/zap"
 	_ "image/png"
+	"io/fs"
 	"log"
 	"os"
+	"strings"
+	"time"
+)
+
+var (
+	seedFlag   = flag.Int64("seed", 0, "Level seed, a random seed is used when 0")
+	recordFlag = flag.String("record", "", "Record every frame of input to this replay file")
+	replayFlag = flag.String("replay", "", "Play back input from this replay file")
+	devFlag    = flag.Bool("dev", false, "Load assets from the source tree and reload them as they change, run from the repository root")
+	assetsFlag = flag.String("assets", "", "Load the base assets from this pack instead of the ones built in")
+	packsFlag  = flag.String("packs", "", "Comma separated packs loaded over the base assets, later packs override earlier ones")
 )
 
 func init() {
@@ -21,29 +38,143 @@ func init() {
 }
 
 func main() {
+	flag.Parse()
+
+	if *recordFlag != "" && *replayFlag != "" {
+		log.Fatal("--record and --replay cannot be used together")
+	}
+
+	// Hot reload watches the loose asset files, a pack has no directory to watch
+	if *devFlag && *assetsFlag != "" {
+		log.Fatal("--dev and --assets cannot be used together")
+	}
+
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run sets up and runs the game, it is split from main so that deferred cleanup such as flushing a recording runs
+// before the process exits.
+func run() error {
+	seed := *seedFlag
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	var source input.Source = &input.Keyboard{}
+	var checksummer game.Checksummer
+	checksumInterval := uint64(replay.DefaultChecksumInterval)
+
+	if *replayFlag != "" {
+		file, err := os.Open(*replayFlag)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		player, err := replay.NewPlayer(file)
+		if err != nil {
+			return err
+		}
+
+		zap.L().Info("Playing replay", zap.String("file", *replayFlag), zap.Int64("seed", player.Seed))
+		seed = player.Seed
+		checksumInterval = player.ChecksumInterval
+		source = player
+		checksummer = player
+	}
+
+	if *recordFlag != "" {
+		file, err := os.Create(*recordFlag)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		recorder, err := replay.NewRecorder(file, replay.Header{Seed: seed, ChecksumInterval: checksumInterval}, source)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := recorder.Close(); err != nil {
+				zap.L().Error("Failed to write replay", zap.Error(err))
+			}
+		}()
+
+		zap.L().Info("Recording replay", zap.String("file", *recordFlag), zap.Int64("seed", seed))
+		source = recorder
+		checksummer = recorder
+	}
+
 	ebiten.SetWindowSize(gfx.ScreenWidth, gfx.ScreenHeight)
 	ebiten.SetWindowTitle("Dungeon")
 
-	playerCharacter := game.NewPlayerCharacter(gfx.ScreenWidth, gfx.ScreenHeight)
+	release := os.Getenv("APP_ENV") == "release"
+	dev := *devFlag && !release
 
-	zap.L().Info("Starting game")
-	objects := make([]*game.Object, 0)
-	objects = append(
-		objects,
-		playerCharacter.Object,
-	)
+	// In dev mode assets are read straight from disk, so edits to them can be picked up without rebuilding
+	var base fs.FS = builtin.FS
+	switch {
+	case *assetsFlag != "":
+		pack, err := assets.OpenPack(*assetsFlag)
+		if err != nil {
+			return err
+		}
+		base = pack
+	case dev:
+		base = os.DirFS("assets")
+	}
 
-	level := game.NewLevel()
-	for _, door := range level.CurrentRoom().Doors {
-		objects = append(objects, door.Object)
+	var packs []string
+	if *packsFlag != "" {
+		packs = strings.Split(*packsFlag, ",")
 	}
 
-	if err := ebiten.RunGame(&game.Game{
-		PlayerCharacter: playerCharacter,
-		Camera:          &game.Camera{ViewPort: numerics.NewVec2(gfx.ScreenWidth, gfx.ScreenHeight)},
-		CurrentLevel:    level,
-		Objects:         objects,
-	}); err != nil {
-		log.Fatal(err)
+	files, manifest, err := assets.Mount(base, packs...)
+	if err != nil {
+		return err
+	}
+
+	dataFS, err := fs.Sub(files, "data")
+	if err != nil {
+		return err
 	}
+
+	// Outside of release builds a missing image shows the placeholder rather than stopping the game
+	manager := assets.NewManager(files, manifest, !release)
+
+	defs, err := game.LoadDefinitions(dataFS, manager)
+	if err != nil {
+		return err
+	}
+
+	wizard, ok := defs.Characters["wizard"]
+	if !ok {
+		return errors.New("no character definition for the wizard")
+	}
+
+	playerCharacter, err := game.NewPlayerCharacter(wizard, gfx.ScreenWidth, gfx.ScreenHeight)
+	if err != nil {
+		return err
+	}
+
+	level, err := game.NewLevel(seed, 1, defs)
+	if err != nil {
+		return err
+	}
+
+	zap.L().Info("Starting game", zap.Int64("seed", seed))
+	g := game.NewGame(playerCharacter, level, source)
+	g.Checksummer = checksummer
+	g.ChecksumInterval = checksumInterval
+
+	if dev {
+		if g.HotReload, err = game.NewHotReload(base, dataFS); err != nil {
+			return err
+		}
+		zap.L().Info("Reloading assets as they change")
+	}
+
+	return ebiten.RunGame(g)
 }
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"dungeon/assets"
+	"dungeon/internal/loot"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"math/rand"
+	"slices"
+)
+
+// lootsim rolls loot tables many times over and prints how often each item drops, to check a table's odds without
+// playing through the game.
+
+var (
+	tableFlag = flag.String("table", "", "Loot table to roll, every table is rolled when empty")
+	rollsFlag = flag.Int("rolls", 10000, "Number of times to roll each table")
+	depthFlag = flag.Int("depth", 1, "Level depth to roll at")
+	seedFlag  = flag.Int64("seed", 1, "Seed for the rolls")
+)
+
+// tally is what one item dropped over every roll of a table.
+type tally struct {
+	// rolls is the number of rolls the item dropped on at all
+	rolls int
+
+	// total is the number of the item dropped across every roll
+	total int
+}
+
+func main() {
+	flag.Parse()
+
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	contents, err := fs.ReadFile(assets.FS, "data/loot.json")
+	if err != nil {
+		return err
+	}
+
+	tables, err := loot.Load(contents)
+	if err != nil {
+		return err
+	}
+
+	names := tables.Names()
+	if *tableFlag != "" {
+		if _, ok := tables[*tableFlag]; !ok {
+			return fmt.Errorf("%w %q", loot.ErrUnknownTable, *tableFlag)
+		}
+		names = []string{*tableFlag}
+	}
+
+	rng := rand.New(rand.NewSource(*seedFlag))
+	for _, name := range names {
+		tallies := make(map[string]*tally)
+		empty := 0
+
+		for i := 0; i < *rollsFlag; i++ {
+			drops, err := tables.Roll(rng, name, *depthFlag)
+			if err != nil {
+				return err
+			}
+
+			if len(drops) == 0 {
+				empty++
+			}
+
+			for _, drop := range drops {
+				t, ok := tallies[drop.Item]
+				if !ok {
+					t = &tally{}
+					tallies[drop.Item] = t
+				}
+				t.rolls++
+				t.total += drop.Count
+			}
+		}
+
+		fmt.Printf("%s (%d rolls at depth %d)\n", name, *rollsFlag, *depthFlag)
+		fmt.Printf("  %-20s %7.2f%%\n", "nothing", percent(empty, *rollsFlag))
+
+		items := make([]string, 0, len(tallies))
+		for item := range tallies {
+			items = append(items, item)
+		}
+		slices.Sort(items)
+
+		for _, item := range items {
+			t := tallies[item]
+			fmt.Printf(
+				"  %-20s %7.2f%%  %.3f per roll\n",
+				item,
+				percent(t.rolls, *rollsFlag),
+				float64(t.total)/float64(*rollsFlag),
+			)
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+// percent returns n as a percentage of total.
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(total)
+}
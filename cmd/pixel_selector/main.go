@@ -9,6 +9,7 @@ import (
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/examples/resources/images"
 	"go.uber.org/zap"
+	"image"
 	"log"
 	"os"
 )
@@ -80,7 +81,10 @@ func (p *PixelSelector) DrawPixelSelector(img *ebiten.Image) {
 			regionY = float32(imgSize.Y) - regionSz
 		}
 
-		tile := game.NewTileFromImage(images.Tiles_png, int(regionX), int(regionY), int(regionSz))
+		// The preview is drawn straight from the image, the region changes with every pixel the mouse moves so packing
+		// each one into an atlas would only fill it up
+		region := image.Rect(int(regionX), int(regionY), int(regionX+regionSz), int(regionY+regionSz))
+		tile := &game.Tile{Image: img.SubImage(region).(*ebiten.Image), Index: -1}
 
 		imgui.Text(fmt.Sprintf("Cursor: (%.2f, %.2f)", regionX, regionY))
 		imgui.Text("Bounds:")
@@ -1,28 +1,16 @@
 package animation
 
 import (
-	"bytes"
-	assets "dungeon/assets/images"
+	"errors"
 	"fmt"
 	"github.com/hajimehoshi/ebiten/v2"
 	"go.uber.org/zap"
 	"image"
-	_ "image/png"
-	"log"
 	"os"
 )
 
-// Globally defined images
-var (
-	WizardFront *Image
-	WizardSide  *Image
-)
-
-func init() {
-	WizardFront = NewImageFromImageBytes(assets.WizardSheet, 3, 0, 24, 24, 24)
-	WizardSide = NewImageFromImageBytes(assets.WizardSheet, 3, 24, 24, 24, 24)
-}
-
+// Image is a sprite sheet and the layout of the frames on it. Frames sit in a grid of equally sized cells, starting
+// from the frame offset and numbered row by row. The default layout is a single column, so frames step down the sheet.
 type Image struct {
 	FrameCount  int
 	FrameOX     int
@@ -30,35 +18,99 @@ type Image struct {
 	FrameWidth  int
 	FrameHeight int
 
+	// Columns is the number of cells in each row of the grid, 0 is the same as 1.
+	Columns int
+
+	// Margin is the gap around the edge of the grid, and Spacing the gap between neighbouring cells.
+	Margin  int
+	Spacing int
+
+	// Frames are the cells played as each frame, in order, so frames can be picked out of the grid or repeated. When
+	// empty frame n is cell n.
+	Frames []int
+
+	// Rects are where each frame sits on the sheet, for sheets which aren't laid out in a grid. When set they replace
+	// the grid entirely.
+	Rects []image.Rectangle
+
 	*ebiten.Image
 }
 
-func NewImageFromFile(filename string, frameCount, frameOX, frameOY, frameWidth, frameHeight int) *Image {
-	// Read the image filename
-	zap.L().Debug("Loading image", zap.String("filename", filename))
+// NewGridImage slices a sheet into a grid of frameWidth by frameHeight cells, fitting as many columns as the sheet is
+// wide. The frames are the cells to play, in order, or every cell when none are given.
+func NewGridImage(img *ebiten.Image, frameWidth, frameHeight, margin, spacing int, frames ...int) (*Image, error) {
+	if frameWidth < 1 || frameHeight < 1 {
+		return nil, fmt.Errorf("frame size %dx%d must be positive", frameWidth, frameHeight)
+	}
 
-	file, err := os.Open(filename)
-	if err != nil {
-		zap.L().Fatal("Failed to open image file", zap.Error(err))
+	size := img.Bounds().Size()
+	columns := (size.X - 2*margin + spacing) / (frameWidth + spacing)
+	rows := (size.Y - 2*margin + spacing) / (frameHeight + spacing)
+	if columns < 1 || rows < 1 {
+		return nil, fmt.Errorf("%dx%d frames don't fit a %dx%d sheet", frameWidth, frameHeight, size.X, size.Y)
 	}
 
-	img, _, err := image.Decode(file)
-	if err != nil {
-		zap.L().Fatal("Failed to decode image", zap.Error(err))
+	count := len(frames)
+	if count == 0 {
+		count = columns * rows
 	}
 
-	if err := file.Close(); err != nil {
-		zap.L().Fatal("Failed to close image file", zap.Error(err))
+	for _, cell := range frames {
+		if cell < 0 || cell >= columns*rows {
+			return nil, fmt.Errorf("frame cell %d is outside the %dx%d grid", cell, columns, rows)
+		}
 	}
 
 	return &Image{
-		FrameCount:  frameCount,
-		FrameOX:     frameOX,
-		FrameOY:     frameOY,
+		FrameCount:  count,
 		FrameWidth:  frameWidth,
 		FrameHeight: frameHeight,
-		Image:       ebiten.NewImageFromImage(img),
+		Columns:     columns,
+		Margin:      margin,
+		Spacing:     spacing,
+		Frames:      frames,
+		Image:       img,
+	}, nil
+}
+
+// Rect returns where frame n sits on the sheet, wrapping around past the last frame.
+func (i *Image) Rect(n int) image.Rectangle {
+	n = ((n % i.FrameCount) + i.FrameCount) % i.FrameCount
+	if len(i.Rects) > 0 {
+		return i.Rects[n%len(i.Rects)]
 	}
+
+	cell := n
+	if len(i.Frames) > 0 {
+		cell = i.Frames[n%len(i.Frames)]
+	}
+
+	columns := max(i.Columns, 1)
+	col, row := cell%columns, cell/columns
+	sx := i.FrameOX + i.Margin + col*(i.FrameWidth+i.Spacing)
+	sy := i.FrameOY + i.Margin + row*(i.FrameHeight+i.Spacing)
+	return image.Rect(sx, sy, sx+i.FrameWidth, sy+i.FrameHeight)
+}
+
+// Frame returns frame n of the image, wrapping around past the last frame.
+func (i *Image) Frame(n int) *ebiten.Image {
+	return i.SubImage(i.Rect(n).Add(i.Bounds().Min)).(*ebiten.Image)
+}
+
+// NewImageFromFile loads an image with frameCount frames in a column from the file.
+func NewImageFromFile(filename string, frameCount, frameOX, frameOY, frameWidth, frameHeight int) (*Image, error) {
+	zap.L().Debug("Loading image", zap.String("filename", filename))
+
+	imgBytes, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := NewImageFromImageBytes(imgBytes, frameCount, frameOX, frameOY, frameWidth, frameHeight)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return img, nil
 }
 
 // NewImageFromImage creates a new image from an existing ebiten image object. This is reserved for filled images.
@@ -74,18 +126,15 @@ func NewImageFromImage(img *ebiten.Image) *Image {
 	}
 }
 
-func NewImageFromImageBytes(imgBytes []byte, frameCount, frameOX, frameOY, frameWidth, frameHeight int) *Image {
+// NewImageFromImageBytes decodes an image with frameCount frames in a column.
+func NewImageFromImageBytes(imgBytes []byte, frameCount, frameOX, frameOY, frameWidth, frameHeight int) (*Image, error) {
 	if frameCount < 1 {
-		log.Fatal("Frame count cannot be < 1")
+		return nil, errors.New("frame count cannot be < 1")
 	}
 
-	img, format, err := image.Decode(bytes.NewReader(imgBytes))
-
-	zap.L().Debug("Image format", zap.String("format", format))
-
+	img, err := LoadImage(imgBytes)
 	if err != nil {
-		fmt.Println(err)
-		zap.L().Fatal("Failed to decode image", zap.Error(err))
+		return nil, err
 	}
 
 	return &Image{
@@ -94,6 +143,6 @@ func NewImageFromImageBytes(imgBytes []byte, frameCount, frameOX, frameOY, frame
 		FrameOY:     frameOY,
 		FrameWidth:  frameWidth,
 		FrameHeight: frameHeight,
-		Image:       ebiten.NewImageFromImage(img),
-	}
+		Image:       img,
+	}, nil
 }
@@ -0,0 +1,499 @@
+package animation
+
+import (
+	"fmt"
+	"github.com/hajimehoshi/ebiten/v2"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultFrameDuration is how long a frame is shown when its clip gives no duration for it.
+const DefaultFrameDuration = 100 * time.Millisecond
+
+// Direction is the way a sprite is facing. Front faces the camera, down the screen.
+type Direction int
+
+const (
+	Front Direction = iota
+	Back
+	Left
+	Right
+
+	// The diagonals are only used by eight-direction sprites
+	FrontLeft
+	FrontRight
+	BackLeft
+	BackRight
+)
+
+func (d Direction) String() string {
+	switch d {
+	case Front:
+		return "Front"
+	case Back:
+		return "Back"
+	case Left:
+		return "Left"
+	case Right:
+		return "Right"
+	case FrontLeft:
+		return "FrontLeft"
+	case FrontRight:
+		return "FrontRight"
+	case BackLeft:
+		return "BackLeft"
+	case BackRight:
+		return "BackRight"
+	default:
+		return "Unknown"
+	}
+}
+
+// Mirror returns the direction reflected left to right. Front and Back are their own mirror.
+func (d Direction) Mirror() Direction {
+	switch d {
+	case Left:
+		return Right
+	case Right:
+		return Left
+	case FrontLeft:
+		return FrontRight
+	case FrontRight:
+		return FrontLeft
+	case BackLeft:
+		return BackRight
+	case BackRight:
+		return BackLeft
+	default:
+		return d
+	}
+}
+
+// side returns the left or right direction a diagonal leans towards, other directions are returned as they are.
+func (d Direction) side() Direction {
+	switch d {
+	case FrontLeft, BackLeft:
+		return Left
+	case FrontRight, BackRight:
+		return Right
+	default:
+		return d
+	}
+}
+
+// DirectionFromAngle returns the direction closest to an angle in radians from the x-axis, with y pointing down the
+// screen. When eight is false only the four straight directions are used.
+func DirectionFromAngle(rad float64, eight bool) Direction {
+	// Angles run clockwise on screen from Right, in steps of a quarter or an eighth of a turn
+	ring := []Direction{Right, Front, Left, Back}
+	if eight {
+		ring = []Direction{Right, FrontRight, Front, FrontLeft, Left, BackLeft, Back, BackRight}
+	}
+
+	step := 2 * math.Pi / float64(len(ring))
+	i := int(math.Round(rad/step)) % len(ring)
+	if i < 0 {
+		i += len(ring)
+	}
+	return ring[i]
+}
+
+// Playback is the order a clip's frames are played in.
+type Playback int
+
+const (
+	// Forward plays from the first frame to the last.
+	Forward Playback = iota
+
+	// Reverse plays from the last frame to the first.
+	Reverse
+
+	// PingPong plays forwards and then back again, without showing the first or last frame twice in a row.
+	PingPong
+)
+
+func (p Playback) String() string {
+	switch p {
+	case Forward:
+		return "Forward"
+	case Reverse:
+		return "Reverse"
+	case PingPong:
+		return "PingPong"
+	default:
+		return "Unknown"
+	}
+}
+
+func (p Playback) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(p.String())), nil
+}
+
+func (p *Playback) UnmarshalText(text []byte) error {
+	for pb := Forward; pb <= PingPong; pb++ {
+		if strings.EqualFold(pb.String(), string(text)) {
+			*p = pb
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown playback %q", text)
+}
+
+// Clip is a named run of frames from a sprite sheet.
+type Clip struct {
+	Name  string
+	Image *Image
+
+	// Durations are how long each frame is shown for in milliseconds, indexed by frame. Frames past the end use the
+	// last duration, and a clip without any uses DefaultFrameDuration.
+	Durations []int
+
+	Playback Playback
+
+	// Loop clips start over after their last frame, others hold it and count as finished.
+	Loop bool
+
+	// Events are fired when playback reaches a frame, keyed by the frame's index in the clip.
+	Events map[int][]string
+}
+
+// Duration returns how long the frame is shown for.
+func (c *Clip) Duration(frame int) time.Duration {
+	if len(c.Durations) == 0 {
+		return DefaultFrameDuration
+	}
+
+	ms := c.Durations[min(max(frame, 0), len(c.Durations)-1)]
+
+	// Frames always take some time, so a clip of zero durations can't spin forever
+	return max(time.Duration(ms)*time.Millisecond, time.Millisecond)
+}
+
+// Length returns the total time of one play through the clip.
+func (c *Clip) Length() time.Duration {
+	var length time.Duration
+	for step := 0; step < c.steps(); step++ {
+		length += c.Duration(c.frameAt(step))
+	}
+	return length
+}
+
+// steps is the number of frames shown in one play through, ping-pong clips pass back over their middle frames.
+func (c *Clip) steps() int {
+	n := c.frameCount()
+	if c.Playback == PingPong && n > 2 {
+		return 2*n - 2
+	}
+	return n
+}
+
+// frameAt returns the frame shown at a step of a play through.
+func (c *Clip) frameAt(step int) int {
+	n := c.frameCount()
+	switch {
+	case c.Playback == Reverse:
+		return n - 1 - step
+	case c.Playback == PingPong && step >= n:
+		return 2*n - 2 - step
+	default:
+		return step
+	}
+}
+
+func (c *Clip) frameCount() int {
+	if c.Image == nil {
+		return 1
+	}
+	return max(c.Image.FrameCount, 1)
+}
+
+// State is a state of an Animator, playing a clip for each direction.
+type State struct {
+	Name  string
+	Clips map[Direction]*Clip
+
+	// Mirror fills in a missing direction by flipping the clip for its mirror image, so a sprite drawn facing right
+	// can face left too.
+	Mirror bool
+
+	// Next is the state a one-shot clip moves on to once it finishes. When it is empty the last frame is held.
+	Next string
+}
+
+// clip returns the clip for the direction and whether it has to be drawn flipped. A missing direction is filled in by
+// mirroring if the state allows it, then diagonals fall back to the side they lean towards and anything else to the
+// front facing clip.
+func (s *State) clip(d Direction) (*Clip, bool) {
+	if c, ok := s.Clips[d]; ok {
+		return c, false
+	}
+
+	if c, ok := s.Clips[d.Mirror()]; ok && s.Mirror {
+		return c, true
+	}
+
+	if side := d.side(); side != d {
+		return s.clip(side)
+	}
+	return s.Clips[Front], false
+}
+
+// Condition decides whether a transition is taken.
+type Condition func(a *Animator) bool
+
+// Is is a condition which holds while the parameter is set.
+func Is(param string) Condition {
+	return func(a *Animator) bool { return a.params[param] }
+}
+
+// Not is a condition which holds while the parameter is not set.
+func Not(param string) Condition {
+	return func(a *Animator) bool { return !a.params[param] }
+}
+
+// Triggered is a condition which holds on the tick the trigger was fired.
+func Triggered(trigger string) Condition {
+	return func(a *Animator) bool { return slices.Contains(a.triggers, trigger) }
+}
+
+// Transition moves an Animator from one state to another when its condition holds.
+type Transition struct {
+	// From is the state the transition leaves, an empty From leaves any state.
+	From string
+	To   string
+	When Condition
+
+	// Interrupts lets the transition cut a one-shot clip short, otherwise it waits for the clip to finish.
+	Interrupts bool
+}
+
+// FrameEvent is an event fired by a clip reaching one of its frames.
+type FrameEvent struct {
+	State string
+	Frame int
+	Name  string
+}
+
+// Animator plays clips from a set of states, moving between them as transitions fire. Transitions check parameters
+// and triggers which the owner sets every tick.
+type Animator struct {
+	// Direction picks which of the state's clips is played.
+	Direction Direction
+
+	// Eight lets Face pick diagonal directions as well as the four straight ones.
+	Eight bool
+
+	// Speed scales the time passed to Update, 2 plays everything twice as fast and 0 pauses.
+	Speed float64
+
+	states      map[string]*State
+	transitions []Transition
+
+	current *State
+
+	// step is how far through the current clip's play through the animator is, and elapsed how long the frame at
+	// that step has been shown
+	step    int
+	elapsed time.Duration
+
+	// finished is set once a one-shot clip has shown its last frame for its full time
+	finished bool
+
+	params   map[string]bool
+	triggers []string
+
+	// events are the frame events fired since the last Update
+	events []FrameEvent
+}
+
+// NewAnimator creates an animator with the states, starting in the named one.
+func NewAnimator(initial string, states ...*State) *Animator {
+	a := &Animator{
+		Speed:  1,
+		states: make(map[string]*State, len(states)),
+		params: make(map[string]bool),
+	}
+
+	for _, s := range states {
+		a.states[s.Name] = s
+	}
+
+	a.enter(initial)
+	return a
+}
+
+// AddTransition adds a transition. Transitions are checked in the order they were added and the first which holds is
+// taken.
+func (a *Animator) AddTransition(t Transition) {
+	a.transitions = append(a.transitions, t)
+}
+
+// Set sets a parameter transitions can check.
+func (a *Animator) Set(param string, v bool) {
+	a.params[param] = v
+}
+
+// Trigger fires a trigger, it is only seen by transitions on the next Update.
+func (a *Animator) Trigger(trigger string) {
+	a.triggers = append(a.triggers, trigger)
+}
+
+// Play jumps straight to the named state, restarting it if it is already playing.
+func (a *Animator) Play(state string) {
+	a.enter(state)
+}
+
+// State returns the name of the current state.
+func (a *Animator) State() string {
+	if a.current == nil {
+		return ""
+	}
+	return a.current.Name
+}
+
+// Finished reports whether the current state's clip is a one-shot which has played through.
+func (a *Animator) Finished() bool {
+	return a.finished
+}
+
+// Face points the animator in the direction closest to an angle in radians from the x-axis.
+func (a *Animator) Face(rad float64) {
+	a.Direction = DirectionFromAngle(rad, a.Eight)
+}
+
+// Clip returns the clip being played.
+func (a *Animator) Clip() *Clip {
+	if a.current == nil {
+		return nil
+	}
+	clip, _ := a.current.clip(a.Direction)
+	return clip
+}
+
+// Mirrored reports whether the clip being played stands in for its mirror image and has to be drawn flipped left to
+// right.
+func (a *Animator) Mirrored() bool {
+	if a.current == nil {
+		return false
+	}
+	_, mirrored := a.current.clip(a.Direction)
+	return mirrored
+}
+
+// FrameIndex returns the index of the frame being shown in the current clip.
+func (a *Animator) FrameIndex() int {
+	clip := a.Clip()
+	if clip == nil {
+		return 0
+	}
+	return clip.frameAt(min(a.step, clip.steps()-1))
+}
+
+// Frame returns the image of the frame being shown, or nil if there is nothing to show.
+func (a *Animator) Frame() *ebiten.Image {
+	clip := a.Clip()
+	if clip == nil || clip.Image == nil {
+		return nil
+	}
+	return clip.Image.Frame(a.FrameIndex())
+}
+
+// Snapshot returns a copy of the animator frozen on the current frame. It must not be updated.
+func (a *Animator) Snapshot() *Animator {
+	c := *a
+	return &c
+}
+
+// Update takes any transition which holds, then advances the clip by dt scaled by the animator's speed, moving on as
+// many frames as that covers. It returns the frame events fired since the last update.
+func (a *Animator) Update(dt time.Duration) []FrameEvent {
+	a.transition()
+	a.triggers = a.triggers[:0]
+
+	if clip := a.Clip(); clip != nil && !a.finished && a.Speed > 0 {
+		a.elapsed += time.Duration(float64(dt) * a.Speed)
+		for !a.finished {
+			d := clip.Duration(a.FrameIndex())
+			if a.elapsed < d {
+				break
+			}
+			a.elapsed -= d
+			a.advance(clip)
+		}
+	}
+
+	events := a.events
+	a.events = nil
+	return events
+}
+
+// transition takes the first transition which holds, or moves a finished one-shot on to its next state.
+func (a *Animator) transition() {
+	playing := a.current != nil && !a.finished && !a.looping()
+
+	for _, t := range a.transitions {
+		if t.From != "" && t.From != a.State() {
+			continue
+		}
+
+		if t.To == a.State() || (playing && !t.Interrupts) {
+			continue
+		}
+
+		if t.When == nil || t.When(a) {
+			a.enter(t.To)
+			return
+		}
+	}
+
+	if a.finished && a.current.Next != "" {
+		a.enter(a.current.Next)
+	}
+}
+
+// looping reports whether the current clip loops.
+func (a *Animator) looping() bool {
+	clip := a.Clip()
+	return clip != nil && clip.Loop
+}
+
+// advance moves on to the next frame of the clip.
+func (a *Animator) advance(clip *Clip) {
+	if a.step < clip.steps()-1 {
+		a.step++
+	} else if clip.Loop {
+		a.step = 0
+	} else {
+		a.finished = true
+		a.elapsed = 0
+		return
+	}
+	a.fire(clip)
+}
+
+// enter switches to the named state and starts its clip from the first frame.
+func (a *Animator) enter(name string) {
+	state, ok := a.states[name]
+	if !ok {
+		return
+	}
+
+	a.current = state
+	a.step = 0
+	a.elapsed = 0
+	a.finished = false
+
+	if clip := a.Clip(); clip != nil {
+		a.fire(clip)
+	}
+}
+
+// fire records the events on the current frame of the clip.
+func (a *Animator) fire(clip *Clip) {
+	frame := a.FrameIndex()
+	for _, name := range clip.Events[frame] {
+		a.events = append(a.events, FrameEvent{State: a.current.Name, Frame: frame, Name: name})
+	}
+}
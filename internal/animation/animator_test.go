@@ -0,0 +1,263 @@
+package animation
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// testState makes a state with a single front facing clip of n frames.
+func testState(name string, n int, loop bool, next string) *State {
+	clip := &Clip{Name: name, Image: &Image{FrameCount: n}, Loop: loop}
+	return &State{Name: name, Clips: map[Direction]*Clip{Front: clip}, Next: next}
+}
+
+// newTestAnimator makes an animator standing idle, which can attack and get hurt. Both are three frame one-shots
+// which go back to idle.
+func newTestAnimator() *Animator {
+	return NewAnimator("idle",
+		testState("idle", 2, true, ""),
+		testState("attack", 3, false, "idle"),
+		testState("hurt", 3, false, "idle"),
+	)
+}
+
+// tick advances the animator by one frame of a clip without durations.
+func tick(a *Animator) []FrameEvent {
+	return a.Update(DefaultFrameDuration)
+}
+
+// tickUntilFinished ticks until the current one-shot has finished, failing if it never does.
+func tickUntilFinished(t *testing.T, a *Animator) {
+	t.Helper()
+	for i := 0; !a.Finished(); i++ {
+		if i > 100 {
+			t.Fatalf("%s never finished", a.State())
+		}
+		tick(a)
+	}
+}
+
+func TestTransitionWaitsForOneShot(t *testing.T) {
+	a := newTestAnimator()
+	a.AddTransition(Transition{To: "hurt", When: Is("hit")})
+
+	a.Play("attack")
+	a.Set("hit", true)
+	tick(a)
+	if a.State() != "attack" {
+		t.Fatalf("state = %s, want the attack to carry on", a.State())
+	}
+
+	tickUntilFinished(t, a)
+	if a.State() != "attack" {
+		t.Fatalf("state = %s, want attack holding its last frame", a.State())
+	}
+
+	tick(a)
+	if a.State() != "hurt" {
+		t.Errorf("state = %s after the attack finished, want hurt", a.State())
+	}
+}
+
+func TestTransitionInterrupts(t *testing.T) {
+	a := newTestAnimator()
+	a.AddTransition(Transition{To: "hurt", When: Is("hit"), Interrupts: true})
+
+	a.Play("attack")
+	tick(a)
+	a.Set("hit", true)
+	tick(a)
+	if a.State() != "hurt" {
+		t.Errorf("state = %s, want the attack cut short by hurt", a.State())
+	}
+}
+
+func TestTransitionFrom(t *testing.T) {
+	a := newTestAnimator()
+	a.AddTransition(Transition{From: "hurt", To: "attack", When: Is("angry")})
+	a.Set("angry", true)
+
+	tick(a)
+	if a.State() != "idle" {
+		t.Errorf("state = %s, want a transition from hurt ignored while idle", a.State())
+	}
+}
+
+func TestOneShotMovesToNext(t *testing.T) {
+	a := newTestAnimator()
+	a.Play("attack")
+
+	tickUntilFinished(t, a)
+	if a.FrameIndex() != 2 {
+		t.Errorf("finished on frame %d, want the last frame held", a.FrameIndex())
+	}
+
+	tick(a)
+	if a.State() != "idle" || a.Finished() {
+		t.Errorf("state = %s, finished = %v, want idle playing", a.State(), a.Finished())
+	}
+}
+
+func TestTriggeredHoldsForOneTick(t *testing.T) {
+	a := newTestAnimator()
+	a.AddTransition(Transition{From: "idle", To: "hurt", When: Triggered("hit")})
+
+	// A trigger fired while nothing can take it is gone by the time something could
+	a.Play("attack")
+	a.Trigger("hit")
+	tickUntilFinished(t, a)
+	tick(a)
+	tick(a)
+	if a.State() != "idle" {
+		t.Fatalf("state = %s, want the stale trigger ignored", a.State())
+	}
+
+	a.Trigger("hit")
+	tick(a)
+	if a.State() != "hurt" {
+		t.Errorf("state = %s, want the trigger taken on the next update", a.State())
+	}
+}
+
+func TestFrameEvents(t *testing.T) {
+	swing := testState("attack", 3, false, "")
+	swing.Clips[Front].Events = map[int][]string{0: {"start"}, 2: {"swing", "sound"}}
+	a := NewAnimator("attack", swing)
+
+	var fired []string
+	for i := 0; i < 5; i++ {
+		for _, e := range tick(a) {
+			if e.State != "attack" {
+				t.Errorf("event %s from state %s, want attack", e.Name, e.State)
+			}
+			fired = append(fired, e.Name)
+		}
+	}
+
+	if want := []string{"start", "swing", "sound"}; !slices.Equal(fired, want) {
+		t.Errorf("fired %v, want %v", fired, want)
+	}
+}
+
+// frames updates the animator by dt count times, returning the frame shown after each update.
+func frames(a *Animator, dt time.Duration, count int) []int {
+	shown := make([]int, count)
+	for i := range shown {
+		a.Update(dt)
+		shown[i] = a.FrameIndex()
+	}
+	return shown
+}
+
+// playing makes an animator playing a single clip.
+func playing(clip *Clip) *Animator {
+	return NewAnimator(clip.Name, &State{Name: clip.Name, Clips: map[Direction]*Clip{Front: clip}})
+}
+
+func TestFrameDurations(t *testing.T) {
+	a := playing(&Clip{Name: "walk", Image: &Image{FrameCount: 3}, Durations: []int{100, 200, 50}, Loop: true})
+
+	steps := []struct {
+		dt    time.Duration
+		frame int
+	}{
+		{99 * time.Millisecond, 0},
+		{time.Millisecond, 1},
+		{199 * time.Millisecond, 1},
+		{time.Millisecond, 2},
+		{50 * time.Millisecond, 0},
+		// A long update skips as many frames as it covers, carrying the rest over
+		{325 * time.Millisecond, 2},
+		{25 * time.Millisecond, 0},
+	}
+	for i, s := range steps {
+		a.Update(s.dt)
+		if got := a.FrameIndex(); got != s.frame {
+			t.Fatalf("step %d: frame %d, want %d", i, got, s.frame)
+		}
+	}
+}
+
+func TestPlaybackOrder(t *testing.T) {
+	tests := []struct {
+		playback Playback
+		count    int
+		want     []int
+	}{
+		{Forward, 4, []int{1, 2, 3, 0, 1}},
+		{Reverse, 4, []int{2, 1, 0, 3, 2}},
+		{PingPong, 4, []int{1, 2, 3, 2, 1, 0, 1, 2}},
+		{PingPong, 2, []int{1, 0, 1, 0}},
+		{PingPong, 1, []int{0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.playback.String(), func(t *testing.T) {
+			a := playing(&Clip{Name: "walk", Image: &Image{FrameCount: tt.count}, Playback: tt.playback, Loop: true})
+			if got := frames(a, DefaultFrameDuration, len(tt.want)); !slices.Equal(got, tt.want) {
+				t.Errorf("%d frames played %v, want %v", tt.count, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClipLength(t *testing.T) {
+	clip := &Clip{Image: &Image{FrameCount: 4}, Durations: []int{10, 20, 30, 40}, Playback: PingPong}
+	// Out through 0, 1, 2 and 3 and back through 2 and 1
+	if got, want := clip.Length(), 150*time.Millisecond; got != want {
+		t.Errorf("ping-pong length = %v, want %v", got, want)
+	}
+
+	// Frames past the durations use the last one, and a clip without durations the default
+	clip = &Clip{Image: &Image{FrameCount: 3}, Durations: []int{10}}
+	if got, want := clip.Length(), 30*time.Millisecond; got != want {
+		t.Errorf("length = %v, want %v", got, want)
+	}
+	clip.Durations = nil
+	if got, want := clip.Length(), 3*DefaultFrameDuration; got != want {
+		t.Errorf("length without durations = %v, want %v", got, want)
+	}
+}
+
+func TestSpeed(t *testing.T) {
+	tests := []struct {
+		speed float64
+		want  []int
+	}{
+		{1, []int{0, 1, 1, 2}},
+		{2, []int{1, 2, 3, 0}},
+		{0.5, []int{0, 0, 0, 1}},
+		{0, []int{0, 0, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		a := playing(&Clip{Name: "walk", Image: &Image{FrameCount: 4}, Loop: true})
+		a.Speed = tt.speed
+		if got := frames(a, DefaultFrameDuration/2, len(tt.want)); !slices.Equal(got, tt.want) {
+			t.Errorf("speed %v played %v, want %v", tt.speed, got, tt.want)
+		}
+	}
+}
+
+func TestOneShotFinishesOnTime(t *testing.T) {
+	a := NewAnimator("attack",
+		&State{Name: "attack", Clips: map[Direction]*Clip{Front: {Image: &Image{FrameCount: 2}, Durations: []int{100, 100}}}, Next: "idle"},
+		testState("idle", 2, true, ""),
+	)
+
+	a.Update(199 * time.Millisecond)
+	if a.Finished() || a.FrameIndex() != 1 {
+		t.Fatalf("frame %d, finished = %v, want the last frame still showing", a.FrameIndex(), a.Finished())
+	}
+
+	a.Update(time.Millisecond)
+	if !a.Finished() || a.State() != "attack" {
+		t.Fatalf("state = %s, finished = %v, want attack finished", a.State(), a.Finished())
+	}
+
+	a.Update(0)
+	if a.State() != "idle" || a.FrameIndex() != 0 {
+		t.Errorf("state = %s on frame %d, want idle from the start", a.State(), a.FrameIndex())
+	}
+}
@@ -0,0 +1,246 @@
+package animation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/hajimehoshi/ebiten/v2"
+	"image"
+	"slices"
+)
+
+// Sheet is a sprite sheet exported from Aseprite, with the frames, tags and slices from its JSON metadata.
+type Sheet struct {
+	Image  *ebiten.Image
+	Frames []SheetFrame
+	Tags   []Tag
+
+	// Slices are the named regions marked on the sprite, with a key for each frame the region changes on.
+	Slices map[string][]SliceKey
+}
+
+// SheetFrame is where a frame sits on the sheet and how long it is shown for in milliseconds.
+type SheetFrame struct {
+	Rect     image.Rectangle
+	Duration int
+}
+
+// Tag is a named run of frames, From and To are inclusive.
+type Tag struct {
+	Name     string
+	From     int
+	To       int
+	Playback Playback
+
+	// Loop is false when the tag is set to play a fixed number of times.
+	Loop bool
+}
+
+// SliceKey is the region of a slice from Frame onwards.
+type SliceKey struct {
+	Frame  int
+	Bounds image.Rectangle
+	Pivot  image.Point
+}
+
+// ParseAseprite reads the JSON metadata exported by Aseprite. The sheet it returns has no image yet, the image's file
+// name is returned alongside it, relative to the metadata file.
+func ParseAseprite(data []byte) (*Sheet, string, error) {
+	var file asepriteFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, "", err
+	}
+
+	sheet, err := file.sheet()
+	if err != nil {
+		return nil, "", err
+	}
+	return sheet, file.Meta.Image, nil
+}
+
+// SetImage gives the sheet its image, checking every frame lies within it.
+func (s *Sheet) SetImage(img *ebiten.Image) error {
+	bounds := image.Rectangle{Max: img.Bounds().Size()}
+	for i, f := range s.Frames {
+		if !f.Rect.In(bounds) {
+			return fmt.Errorf("frame %d: %v is outside the image", i, f.Rect)
+		}
+	}
+
+	s.Image = img
+	return nil
+}
+
+// Tag returns the tag with the name.
+func (s *Sheet) Tag(name string) (*Tag, bool) {
+	i := slices.IndexFunc(s.Tags, func(t Tag) bool { return t.Name == name })
+	if i < 0 {
+		return nil, false
+	}
+	return &s.Tags[i], true
+}
+
+// Clip makes a clip playing the frames of the tag, with the tag's playback and the frames' durations.
+func (s *Sheet) Clip(tag string) (*Clip, error) {
+	t, ok := s.Tag(tag)
+	if !ok {
+		return nil, fmt.Errorf("unknown tag %q", tag)
+	}
+
+	frames := s.Frames[t.From : t.To+1]
+	img := &Image{
+		FrameCount:  len(frames),
+		FrameWidth:  frames[0].Rect.Dx(),
+		FrameHeight: frames[0].Rect.Dy(),
+		Rects:       make([]image.Rectangle, len(frames)),
+		Image:       s.Image,
+	}
+
+	durations := make([]int, len(frames))
+	for i, f := range frames {
+		img.Rects[i] = f.Rect
+		durations[i] = f.Duration
+	}
+
+	return &Clip{Name: t.Name, Image: img, Durations: durations, Playback: t.Playback, Loop: t.Loop}, nil
+}
+
+// Slice returns the region of the named slice on a frame, from the last key at or before it.
+func (s *Sheet) Slice(name string, frame int) (SliceKey, bool) {
+	var key SliceKey
+	found := false
+	for _, k := range s.Slices[name] {
+		if k.Frame <= frame && (!found || k.Frame >= key.Frame) {
+			key, found = k, true
+		}
+	}
+	return key, found
+}
+
+type asepriteRect struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+func (r asepriteRect) rect() image.Rectangle {
+	return image.Rect(r.X, r.Y, r.X+r.W, r.Y+r.H)
+}
+
+type asepriteFrame struct {
+	Filename string       `json:"filename"`
+	Frame    asepriteRect `json:"frame"`
+	Rotated  bool         `json:"rotated"`
+	Trimmed  bool         `json:"trimmed"`
+	Duration int          `json:"duration"`
+}
+
+// asepriteFrames are the frames of an export, which Aseprite writes either as an array or as an object keyed by file
+// name. Objects keep the order they were written in, since tags refer to frames by index.
+type asepriteFrames []asepriteFrame
+
+func (f *asepriteFrames) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		return json.Unmarshal(data, (*[]asepriteFrame)(f))
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		var frame asepriteFrame
+		if err := dec.Decode(&frame); err != nil {
+			return err
+		}
+		frame.Filename = key.(string)
+		*f = append(*f, frame)
+	}
+	return nil
+}
+
+type asepriteFile struct {
+	Frames asepriteFrames `json:"frames"`
+	Meta   struct {
+		Image     string `json:"image"`
+		FrameTags []struct {
+			Name      string `json:"name"`
+			From      int    `json:"from"`
+			To        int    `json:"to"`
+			Direction string `json:"direction"`
+			Repeat    string `json:"repeat"`
+		} `json:"frameTags"`
+		Slices []struct {
+			Name string `json:"name"`
+			Keys []struct {
+				Frame  int          `json:"frame"`
+				Bounds asepriteRect `json:"bounds"`
+				Pivot  *image.Point `json:"pivot"`
+			} `json:"keys"`
+		} `json:"slices"`
+	} `json:"meta"`
+}
+
+// sheet converts the export to a sheet.
+func (f *asepriteFile) sheet() (*Sheet, error) {
+	sheet := &Sheet{
+		Frames: make([]SheetFrame, len(f.Frames)),
+		Slices: make(map[string][]SliceKey, len(f.Meta.Slices)),
+	}
+
+	for i, frame := range f.Frames {
+		if frame.Rotated {
+			return nil, fmt.Errorf("frame %q: rotated frames are not supported", frame.Filename)
+		}
+
+		// Trimmed frames are cut to their content, so each one has its own size and offset, which clips can't follow
+		if frame.Trimmed {
+			return nil, fmt.Errorf("frame %q: trimmed frames are not supported, export without trimming", frame.Filename)
+		}
+
+		rect := frame.Frame.rect()
+		if rect.Empty() {
+			return nil, fmt.Errorf("frame %q is empty", frame.Filename)
+		}
+		sheet.Frames[i] = SheetFrame{Rect: rect, Duration: frame.Duration}
+	}
+
+	for _, tag := range f.Meta.FrameTags {
+		if tag.From < 0 || tag.To < tag.From || tag.To >= len(sheet.Frames) {
+			return nil, fmt.Errorf("tag %q: frames %d to %d are out of range", tag.Name, tag.From, tag.To)
+		}
+
+		t := Tag{Name: tag.Name, From: tag.From, To: tag.To, Loop: tag.Repeat == "" || tag.Repeat == "0"}
+		switch tag.Direction {
+		case "", "forward":
+			t.Playback = Forward
+		case "reverse":
+			t.Playback = Reverse
+		case "pingpong":
+			t.Playback = PingPong
+		default:
+			return nil, fmt.Errorf("tag %q: unsupported direction %q", tag.Name, tag.Direction)
+		}
+		sheet.Tags = append(sheet.Tags, t)
+	}
+
+	for _, slice := range f.Meta.Slices {
+		for _, key := range slice.Keys {
+			k := SliceKey{Frame: key.Frame, Bounds: key.Bounds.rect()}
+			if key.Pivot != nil {
+				k.Pivot = *key.Pivot
+			}
+			sheet.Slices[slice.Name] = append(sheet.Slices[slice.Name], k)
+		}
+	}
+
+	return sheet, nil
+}
@@ -0,0 +1,162 @@
+package animation
+
+import (
+	"image"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// asepriteMeta is the meta block shared by the test exports: three tags over four frames and a slice keyed on two of
+// them.
+const asepriteMeta = `"meta": {
+	"image": "hero.png",
+	"frameTags": [
+		{"name": "idle", "from": 0, "to": 1, "direction": "forward"},
+		{"name": "walk", "from": 1, "to": 3, "direction": "pingpong", "repeat": "0"},
+		{"name": "die", "from": 2, "to": 3, "direction": "reverse", "repeat": "1"}
+	],
+	"slices": [
+		{"name": "hitbox", "keys": [
+			{"frame": 0, "bounds": {"x": 4, "y": 2, "w": 8, "h": 12}, "pivot": {"x": 8, "y": 14}},
+			{"frame": 2, "bounds": {"x": 5, "y": 3, "w": 6, "h": 10}}
+		]}
+	]
+}`
+
+const asepriteArray = `{
+	"frames": [
+		{"filename": "hero 0.aseprite", "frame": {"x": 0, "y": 0, "w": 16, "h": 16}, "duration": 100},
+		{"filename": "hero 1.aseprite", "frame": {"x": 16, "y": 0, "w": 16, "h": 16}, "duration": 150},
+		{"filename": "hero 2.aseprite", "frame": {"x": 32, "y": 0, "w": 16, "h": 16}, "duration": 200},
+		{"filename": "hero 3.aseprite", "frame": {"x": 48, "y": 0, "w": 16, "h": 16}, "duration": 250}
+	],
+	` + asepriteMeta + `
+}`
+
+// asepriteHash is the same export with frames keyed by file name. The names don't sort in frame order, so the order
+// has to come from the file.
+const asepriteHash = `{
+	"frames": {
+		"d.aseprite": {"frame": {"x": 0, "y": 0, "w": 16, "h": 16}, "duration": 100},
+		"c.aseprite": {"frame": {"x": 16, "y": 0, "w": 16, "h": 16}, "duration": 150},
+		"b.aseprite": {"frame": {"x": 32, "y": 0, "w": 16, "h": 16}, "duration": 200},
+		"a.aseprite": {"frame": {"x": 48, "y": 0, "w": 16, "h": 16}, "duration": 250}
+	},
+	` + asepriteMeta + `
+}`
+
+func TestParseAseprite(t *testing.T) {
+	wantFrames := []SheetFrame{
+		{Rect: image.Rect(0, 0, 16, 16), Duration: 100},
+		{Rect: image.Rect(16, 0, 32, 16), Duration: 150},
+		{Rect: image.Rect(32, 0, 48, 16), Duration: 200},
+		{Rect: image.Rect(48, 0, 64, 16), Duration: 250},
+	}
+	wantTags := []Tag{
+		{Name: "idle", From: 0, To: 1, Playback: Forward, Loop: true},
+		{Name: "walk", From: 1, To: 3, Playback: PingPong, Loop: true},
+		{Name: "die", From: 2, To: 3, Playback: Reverse, Loop: false},
+	}
+
+	for name, data := range map[string]string{"array": asepriteArray, "hash": asepriteHash} {
+		t.Run(name, func(t *testing.T) {
+			sheet, file, err := ParseAseprite([]byte(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if file != "hero.png" {
+				t.Errorf("image = %q, want hero.png", file)
+			}
+			if !slices.Equal(sheet.Frames, wantFrames) {
+				t.Errorf("frames = %v, want %v", sheet.Frames, wantFrames)
+			}
+			if !slices.Equal(sheet.Tags, wantTags) {
+				t.Errorf("tags = %v, want %v", sheet.Tags, wantTags)
+			}
+		})
+	}
+}
+
+func TestSheetClip(t *testing.T) {
+	sheet, _, err := ParseAseprite([]byte(asepriteArray))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clip, err := sheet.Clip("walk")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if clip.Playback != PingPong || !clip.Loop {
+		t.Errorf("playback = %v, loop = %v, want pingpong looping", clip.Playback, clip.Loop)
+	}
+	if want := []int{150, 200, 250}; !slices.Equal(clip.Durations, want) {
+		t.Errorf("durations = %v, want %v", clip.Durations, want)
+	}
+	if clip.Image.FrameCount != 3 || clip.Image.FrameWidth != 16 || clip.Image.FrameHeight != 16 {
+		t.Errorf("image has %d %dx%d frames, want 3 16x16", clip.Image.FrameCount, clip.Image.FrameWidth, clip.Image.FrameHeight)
+	}
+	for i, want := range sheet.Frames[1:] {
+		if got := clip.Image.Rect(i); got != want.Rect {
+			t.Errorf("frame %d = %v, want %v", i, got, want.Rect)
+		}
+	}
+
+	if _, err := sheet.Clip("run"); err == nil {
+		t.Error("Clip of an unknown tag succeeded")
+	}
+}
+
+func TestSheetSlice(t *testing.T) {
+	sheet, _, err := ParseAseprite([]byte(asepriteArray))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := SliceKey{Frame: 0, Bounds: image.Rect(4, 2, 12, 14), Pivot: image.Pt(8, 14)}
+	second := SliceKey{Frame: 2, Bounds: image.Rect(5, 3, 11, 13)}
+	for frame, want := range []SliceKey{first, first, second, second} {
+		if got, ok := sheet.Slice("hitbox", frame); !ok || got != want {
+			t.Errorf("frame %d: slice = %v, %v, want %v", frame, got, ok, want)
+		}
+	}
+
+	if _, ok := sheet.Slice("hurtbox", 0); ok {
+		t.Error("found a slice which doesn't exist")
+	}
+}
+
+func TestParseAsepriteErrors(t *testing.T) {
+	frame := `{"frame": {"x": 0, "y": 0, "w": 16, "h": 16}, "duration": 100`
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"rotated", `{"frames": [` + frame + `, "rotated": true}]}`, "rotated"},
+		{"trimmed", `{"frames": [` + frame + `, "trimmed": true}]}`, "trimmed"},
+		{"empty frame", `{"frames": [{"frame": {"x": 0, "y": 0, "w": 0, "h": 16}}]}`, "empty"},
+		{
+			"tag out of range",
+			`{"frames": [` + frame + `}], "meta": {"frameTags": [{"name": "idle", "from": 0, "to": 1}]}}`,
+			"out of range",
+		},
+		{
+			"unknown direction",
+			`{"frames": [` + frame + `}], "meta": {"frameTags": [{"name": "idle", "from": 0, "to": 0, "direction": "sideways"}]}}`,
+			"direction",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ParseAseprite([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
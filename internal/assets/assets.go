@@ -0,0 +1,247 @@
+// Package assets loads images, sprite sheets and fonts by the names the manifest gives them, decoding each file once
+// and packing images into a shared texture atlas. Assets come from loose files or from pack files layered over them.
+package assets
+
+import (
+	"bytes"
+	"dungeon/internal/animation"
+	"dungeon/internal/atlas"
+	"errors"
+	"fmt"
+	"github.com/hajimehoshi/ebiten/v2"
+	"image"
+	"image/color"
+	_ "image/png"
+	"io/fs"
+	"path"
+)
+
+const (
+	// pageSize is the width and height of each texture atlas page
+	pageSize = 1024
+
+	// padding is the gap left between images packed into the atlas
+	padding = 1
+
+	// placeholderSize is the width and height of the missing texture placeholder, and checkerSize the size of its
+	// checks
+	placeholderSize = 16
+	checkerSize     = 4
+
+	// placeholderName is the name the placeholder is packed into the atlas under
+	placeholderName = "missing texture"
+)
+
+// Manager loads assets by name from a file system. Every asset is only loaded once, later requests for it share the
+// same result.
+type Manager struct {
+	fsys     fs.FS
+	manifest Manifest
+	textures *atlas.Atlas
+
+	// placeholders swaps images which fail to load for a placeholder, so a bad file shows up on screen rather than
+	// stopping the game. Errors are still returned alongside the placeholder.
+	placeholders bool
+
+	decoded map[string]image.Image
+	images  map[string]*ebiten.Image
+	sheets  map[string]*animation.Sheet
+
+	// sheetImages is the image file each loaded sheet uses, keyed by sheet name
+	sheetImages map[string]string
+}
+
+// NewManager creates a manager loading from the file system, finding assets through the manifest. Release builds
+// should turn placeholders off, so a missing asset fails loudly instead.
+func NewManager(fsys fs.FS, manifest Manifest, placeholders bool) *Manager {
+	return &Manager{
+		fsys:         fsys,
+		manifest:     manifest,
+		textures:     atlas.New(pageSize, padding),
+		placeholders: placeholders,
+		decoded:      make(map[string]image.Image),
+		images:       make(map[string]*ebiten.Image),
+		sheets:       make(map[string]*animation.Sheet),
+		sheetImages:  make(map[string]string),
+	}
+}
+
+// Atlas returns the atlas images are packed into.
+func (m *Manager) Atlas() *atlas.Atlas {
+	return m.textures
+}
+
+// Manifest returns the manifest assets are found through.
+func (m *Manager) Manifest() Manifest {
+	return m.manifest
+}
+
+// path returns the file of the asset with the name. Names missing from the manifest are taken as paths, so files can
+// still be loaded directly.
+func (m *Manager) path(name string) string {
+	if entry, ok := m.manifest[name]; ok {
+		return entry.Path
+	}
+	return name
+}
+
+// Read returns the contents of the asset with the name, for assets such as fonts which are used as they are.
+func (m *Manager) Read(name string) ([]byte, error) {
+	return fs.ReadFile(m.fsys, m.path(name))
+}
+
+// Decode returns the decoded image with the name, for anything which needs its pixels rather than a texture.
+func (m *Manager) Decode(name string) (image.Image, error) {
+	name = m.path(name)
+	if img, ok := m.decoded[name]; ok {
+		return img, nil
+	}
+
+	data, err := fs.ReadFile(m.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", name, err)
+	}
+
+	m.decoded[name] = img
+	return img, nil
+}
+
+// Image returns the image with the name, packed into the atlas. If it can't be loaded and placeholders are on, the
+// placeholder is returned along with the error.
+func (m *Manager) Image(name string) (*ebiten.Image, error) {
+	name = m.path(name)
+	if img, ok := m.images[name]; ok {
+		return img, nil
+	}
+
+	img, err := m.loadImage(name)
+	if err != nil {
+		if m.placeholders {
+			return m.Placeholder(), err
+		}
+		return nil, err
+	}
+
+	m.images[name] = img
+	return img, nil
+}
+
+func (m *Manager) loadImage(name string) (*ebiten.Image, error) {
+	decoded, err := m.Decode(name)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := m.textures.Replace(name, decoded)
+	if errors.Is(err, atlas.ErrTooLarge) {
+		// Images bigger than a page, such as large tilesets, get a texture of their own
+		return ebiten.NewImageFromImage(decoded), nil
+	}
+	return img, err
+}
+
+// Grid returns the image with the name sliced into frames by the grid the manifest gives it.
+func (m *Manager) Grid(name string) (*animation.Image, error) {
+	entry, ok := m.manifest[name]
+	if !ok || entry.Grid == nil {
+		return nil, fmt.Errorf("%s has no grid", name)
+	}
+
+	// The placeholder is too small to slice, so a failed image is returned as a single frame of it
+	img, err := m.Image(name)
+	if err != nil {
+		if img == nil {
+			return nil, err
+		}
+		return animation.NewImageFromImage(img), err
+	}
+
+	grid := entry.Grid
+	return animation.NewGridImage(img, grid.FrameWidth, grid.FrameHeight, grid.Margin, grid.Spacing, grid.Frames...)
+}
+
+// Invalidate forgets what was loaded from the file, so it is loaded again the next time it is asked for. Sheets using
+// an image are forgotten along with it.
+func (m *Manager) Invalidate(name string) {
+	delete(m.decoded, name)
+	delete(m.images, name)
+	delete(m.sheets, name)
+
+	for sheet, img := range m.sheetImages {
+		if img == name {
+			delete(m.sheets, sheet)
+		}
+	}
+}
+
+// Sheet returns the Aseprite sprite sheet whose JSON metadata has the name. If the sheet's image can't be loaded and
+// placeholders are on, every frame of the sheet shows the placeholder and the error is returned along with it.
+func (m *Manager) Sheet(name string) (*animation.Sheet, error) {
+	name = m.path(name)
+	if sheet, ok := m.sheets[name]; ok {
+		return sheet, nil
+	}
+
+	data, err := fs.ReadFile(m.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	sheet, imageName, err := animation.ParseAseprite(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	imagePath := path.Join(path.Dir(name), imageName)
+	m.sheetImages[name] = imagePath
+
+	img, err := m.loadImage(imagePath)
+	if err == nil {
+		err = sheet.SetImage(img)
+	}
+
+	if err != nil {
+		if !m.placeholders {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		placeholder := m.Placeholder()
+		for i := range sheet.Frames {
+			sheet.Frames[i].Rect = image.Rectangle{Max: placeholder.Bounds().Size()}
+		}
+		sheet.Image = placeholder
+		return sheet, fmt.Errorf("%s: %w", name, err)
+	}
+
+	m.sheets[name] = sheet
+	return sheet, nil
+}
+
+// Placeholder returns the missing texture placeholder, a magenta and black checkerboard.
+func (m *Manager) Placeholder() *ebiten.Image {
+	if img, ok := m.textures.Get(placeholderName); ok {
+		return img
+	}
+
+	checker := image.NewRGBA(image.Rect(0, 0, placeholderSize, placeholderSize))
+	for y := 0; y < placeholderSize; y++ {
+		for x := 0; x < placeholderSize; x++ {
+			c := color.RGBA{A: 0xff}
+			if (x/checkerSize+y/checkerSize)%2 == 0 {
+				c = color.RGBA{R: 0xff, B: 0xff, A: 0xff}
+			}
+			checker.SetRGBA(x, y, c)
+		}
+	}
+
+	img, err := m.textures.Add(placeholderName, checker)
+	if err != nil {
+		return ebiten.NewImageFromImage(checker)
+	}
+	return img
+}
@@ -0,0 +1,152 @@
+package assets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ManifestFile is the name of the manifest at the root of the assets and of every pack.
+const ManifestFile = "manifest.json"
+
+// Type is the kind of an asset, which decides how it is loaded.
+type Type int
+
+const (
+	// ImageType is a PNG image, optionally sliced into a grid of frames.
+	ImageType Type = iota
+
+	// SheetType is an Aseprite sprite sheet's JSON metadata, its image is found through the metadata.
+	SheetType
+
+	// FontType is a TrueType font.
+	FontType
+
+	// DataType is a JSON definition file.
+	DataType
+)
+
+func (t Type) String() string {
+	switch t {
+	case ImageType:
+		return "Image"
+	case SheetType:
+		return "Sheet"
+	case FontType:
+		return "Font"
+	case DataType:
+		return "Data"
+	default:
+		return "Unknown"
+	}
+}
+
+func (t Type) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(t.String())), nil
+}
+
+func (t *Type) UnmarshalText(text []byte) error {
+	for tt := ImageType; tt <= DataType; tt++ {
+		if strings.EqualFold(tt.String(), string(text)) {
+			*t = tt
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown asset type %q", text)
+}
+
+// Grid is how an image is sliced into frames, see animation.NewGridImage.
+type Grid struct {
+	FrameWidth  int `json:"frame_width"`
+	FrameHeight int `json:"frame_height"`
+	Margin      int `json:"margin"`
+	Spacing     int `json:"spacing"`
+
+	// Frames picks out cells of the grid by index, all of them are used when empty.
+	Frames []int `json:"frames"`
+}
+
+// Entry describes one asset in the manifest.
+type Entry struct {
+	Type Type `json:"type"`
+
+	// Path is where the asset's file is, relative to the root of the assets.
+	Path string `json:"path"`
+
+	// Grid slices an image into frames, only images can have one.
+	Grid *Grid `json:"grid"`
+
+	// Tags group assets, such as every asset a level or a character needs.
+	Tags []string `json:"tags"`
+}
+
+// Manifest describes every asset, keyed by the logical name the game asks for it by.
+type Manifest map[string]*Entry
+
+// ReadManifest reads the manifest at the root of the file system.
+func ReadManifest(fsys fs.FS) (Manifest, error) {
+	data, err := fs.ReadFile(fsys, ManifestFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("%s: %w", ManifestFile, err)
+	}
+	return manifest, nil
+}
+
+// Merge adds the entries of another manifest, replacing any with the same name.
+func (m Manifest) Merge(other Manifest) {
+	for name, entry := range other {
+		m[name] = entry
+	}
+}
+
+// Names returns the names of every asset in lexical order.
+func (m Manifest) Names() []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Tagged returns the names of the assets with the tag, in lexical order.
+func (m Manifest) Tagged(tag string) []string {
+	names := make([]string, 0)
+	for _, name := range m.Names() {
+		if slices.Contains(m[name].Tags, tag) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Validate checks every entry is well formed and its file exists in the file system.
+func (m Manifest) Validate(fsys fs.FS) error {
+	var errs []error
+	for _, name := range m.Names() {
+		entry := m[name]
+		switch {
+		case !fs.ValidPath(entry.Path) || entry.Path == ".":
+			errs = append(errs, fmt.Errorf("%s: invalid path %q", name, entry.Path))
+			continue
+		case entry.Grid != nil && entry.Type != ImageType:
+			errs = append(errs, fmt.Errorf("%s: only images can have a grid", name))
+		case entry.Grid != nil && (entry.Grid.FrameWidth <= 0 || entry.Grid.FrameHeight <= 0):
+			errs = append(errs, fmt.Errorf("%s: grid frames must have a size", name))
+		}
+
+		if _, err := fs.Stat(fsys, entry.Path); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,289 @@
+package assets
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"time"
+)
+
+// PackVersion is the version of the pack format written by WritePack. Packs from newer versions are refused.
+const PackVersion = 1
+
+// packMagic starts every pack file
+var packMagic = [4]byte{'D', 'P', 'A', 'K'}
+
+var (
+	// ErrNotPack is returned when reading a file which isn't a pack.
+	ErrNotPack = errors.New("not an asset pack")
+
+	// ErrPackVersion is returned when reading a pack written by a newer version of the format.
+	ErrPackVersion = errors.New("unsupported asset pack version")
+
+	// ErrPackChecksum is returned when a pack's contents don't match its checksum, usually because it is truncated or
+	// corrupt.
+	ErrPackChecksum = errors.New("asset pack checksum mismatch")
+)
+
+// packHeader starts a pack. It is followed by the index, a name and size for each file, and then the contents of each
+// file in index order. The checksum is a SHA-256 of the whole pack, taken with the checksum itself zeroed.
+type packHeader struct {
+	Magic    [4]byte
+	Version  uint16
+	Count    uint32
+	Checksum [sha256.Size]byte
+}
+
+// indexEntrySize is the size of an index entry for a file with an empty name, the least any entry can take
+const indexEntrySize = 2 + 4
+
+// checksum returns the SHA-256 of a pack with the header and everything after it.
+func (h packHeader) checksum(rest ...[]byte) [sha256.Size]byte {
+	h.Checksum = [sha256.Size]byte{}
+
+	hash := sha256.New()
+
+	// Writes to a hash can't fail
+	_ = binary.Write(hash, binary.LittleEndian, h)
+	for _, b := range rest {
+		hash.Write(b)
+	}
+
+	var sum [sha256.Size]byte
+	copy(sum[:], hash.Sum(nil))
+	return sum
+}
+
+// WritePack writes a pack holding the named files from the file system. Files are stored in lexical order, so the same
+// files always make the same pack.
+func WritePack(w io.Writer, fsys fs.FS, names []string) error {
+	names = append([]string(nil), names...)
+	sort.Strings(names)
+
+	var index, contents bytes.Buffer
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		// Writes to a bytes.Buffer can't fail
+		_ = binary.Write(&index, binary.LittleEndian, uint16(len(name)))
+		index.WriteString(name)
+		_ = binary.Write(&index, binary.LittleEndian, uint32(len(data)))
+		contents.Write(data)
+	}
+
+	header := packHeader{Magic: packMagic, Version: PackVersion, Count: uint32(len(names))}
+	header.Checksum = header.checksum(index.Bytes(), contents.Bytes())
+
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	if _, err := w.Write(index.Bytes()); err != nil {
+		return err
+	}
+	_, err := w.Write(contents.Bytes())
+	return err
+}
+
+// Pack is a read only file system of the files in a pack file. It only holds files, so it can't be walked or listed.
+type Pack struct {
+	files   map[string][]byte
+	modTime time.Time
+}
+
+// OpenPack reads the pack file.
+func OpenPack(file string) (*Pack, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	pack, err := ReadPack(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	if info, err := os.Stat(file); err == nil {
+		pack.modTime = info.ModTime()
+	}
+	return pack, nil
+}
+
+// ReadPack reads a pack from its bytes, checking its version and checksum.
+func ReadPack(data []byte) (*Pack, error) {
+	r := bytes.NewReader(data)
+
+	var header packHeader
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil || header.Magic != packMagic {
+		return nil, ErrNotPack
+	}
+	if header.Version > PackVersion {
+		return nil, fmt.Errorf("%w %d", ErrPackVersion, header.Version)
+	}
+
+	if header.checksum(data[len(data)-r.Len():]) != header.Checksum {
+		return nil, ErrPackChecksum
+	}
+
+	// Don't trust the count or sizes with allocations bigger than the pack could hold
+	if int64(header.Count)*indexEntrySize > int64(r.Len()) {
+		return nil, fmt.Errorf("index of %d files is longer than the pack: %w", header.Count, ErrPackChecksum)
+	}
+
+	names := make([]string, header.Count)
+	sizes := make([]uint32, header.Count)
+	for i := range names {
+		var length uint16
+		if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+			return nil, fmt.Errorf("reading index: %w", err)
+		}
+
+		name := make([]byte, length)
+		if _, err := io.ReadFull(r, name); err != nil {
+			return nil, fmt.Errorf("reading index: %w", err)
+		}
+		if err := binary.Read(r, binary.LittleEndian, &sizes[i]); err != nil {
+			return nil, fmt.Errorf("reading index: %w", err)
+		}
+		names[i] = string(name)
+	}
+
+	pack := &Pack{files: make(map[string][]byte, len(names))}
+	for i, name := range names {
+		if int64(sizes[i]) > int64(r.Len()) {
+			return nil, fmt.Errorf("%s: %w", name, io.ErrUnexpectedEOF)
+		}
+
+		contents := make([]byte, sizes[i])
+		if _, err := io.ReadFull(r, contents); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		pack.files[name] = contents
+	}
+	return pack, nil
+}
+
+// Names returns the names of the files in the pack in lexical order.
+func (p *Pack) Names() []string {
+	names := make([]string, 0, len(p.files))
+	for name := range p.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (p *Pack) Open(name string) (fs.File, error) {
+	data, ok := p.files[name]
+	if !ok || !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &packFile{Reader: bytes.NewReader(data), info: packFileInfo{name: name, size: len(data), modTime: p.modTime}}, nil
+}
+
+func (p *Pack) ReadFile(name string) ([]byte, error) {
+	data, ok := p.files[name]
+	if !ok || !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	return bytes.Clone(data), nil
+}
+
+// packFile is an open file from a pack.
+type packFile struct {
+	*bytes.Reader
+	info packFileInfo
+}
+
+func (f *packFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *packFile) Close() error {
+	return nil
+}
+
+// packFileInfo describes a file in a pack, which takes the modification time of the pack itself.
+type packFileInfo struct {
+	name    string
+	size    int
+	modTime time.Time
+}
+
+func (i packFileInfo) Name() string {
+	return path.Base(i.name)
+}
+
+func (i packFileInfo) Size() int64 {
+	return int64(i.size)
+}
+
+func (i packFileInfo) Mode() fs.FileMode {
+	return 0o444
+}
+
+func (i packFileInfo) ModTime() time.Time {
+	return i.modTime
+}
+
+func (i packFileInfo) IsDir() bool {
+	return false
+}
+
+func (i packFileInfo) Sys() any {
+	return nil
+}
+
+// Overlay is a file system made of layers, where files in later layers hide those with the same name in earlier ones.
+// It is how packs override the base assets.
+type Overlay []fs.FS
+
+func (o Overlay) Open(name string) (fs.File, error) {
+	for i := len(o) - 1; i >= 0; i-- {
+		f, err := o[i].Open(name)
+		if !errors.Is(err, fs.ErrNotExist) {
+			return f, err
+		}
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// Mount layers the packs over the base assets, in order, so each pack overrides the base and the packs before it. The
+// manifests of the base and every pack are merged, letting packs both replace assets and add new ones, and the result is
+// checked so a missing or malformed asset is reported straight away.
+func Mount(base fs.FS, packs ...string) (fs.FS, Manifest, error) {
+	manifest, err := ReadManifest(base)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	layers := Overlay{base}
+	for _, file := range packs {
+		pack, err := OpenPack(file)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// A pack only needs a manifest if it adds assets, replacing files is enough to override them
+		extra, err := ReadManifest(pack)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, fmt.Errorf("%s: %w", file, err)
+		}
+		manifest.Merge(extra)
+		layers = append(layers, pack)
+	}
+
+	if err := manifest.Validate(layers); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", ManifestFile, err)
+	}
+	return layers, manifest, nil
+}
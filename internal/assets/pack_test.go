@@ -0,0 +1,168 @@
+package assets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+// testFiles are the files packed by the tests.
+var testFiles = fstest.MapFS{
+	"data/items.json":   {Data: []byte(`{"sword": {}}`)},
+	"images/hero.png":   {Data: []byte("not really a png")},
+	"fonts/empty.ttf":   {Data: []byte{}},
+	"images/unused.png": {Data: []byte("left out of the pack")},
+}
+
+// writeTestPack packs the named test files.
+func writeTestPack(t *testing.T, fsys fs.FS, names ...string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := WritePack(&buf, fsys, names); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestPackRoundTrip(t *testing.T) {
+	names := []string{"images/hero.png", "data/items.json", "fonts/empty.ttf"}
+	pack, err := ReadPack(writeTestPack(t, testFiles, names...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"data/items.json", "fonts/empty.ttf", "images/hero.png"}; !slices.Equal(pack.Names(), want) {
+		t.Errorf("names = %v, want %v", pack.Names(), want)
+	}
+
+	for _, name := range names {
+		data, err := fs.ReadFile(pack, name)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		} else if !bytes.Equal(data, testFiles[name].Data) {
+			t.Errorf("%s = %q, want %q", name, data, testFiles[name].Data)
+		}
+	}
+
+	if _, err := pack.Open("images/unused.png"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("opening a file left out of the pack: %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestPackSameFilesSameBytes(t *testing.T) {
+	a := writeTestPack(t, testFiles, "images/hero.png", "data/items.json")
+	b := writeTestPack(t, testFiles, "data/items.json", "images/hero.png")
+	if !bytes.Equal(a, b) {
+		t.Error("packing the same files in a different order made a different pack")
+	}
+}
+
+func TestReadPackRejects(t *testing.T) {
+	valid := writeTestPack(t, testFiles, "images/hero.png", "data/items.json")
+	headerSize := binary.Size(packHeader{})
+
+	// edit returns a copy of the valid pack changed by fn
+	edit := func(fn func(b []byte) []byte) []byte {
+		return fn(bytes.Clone(valid))
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"flipped content byte", edit(func(b []byte) []byte { b[len(b)-1] ^= 1; return b }), ErrPackChecksum},
+		{"flipped index byte", edit(func(b []byte) []byte { b[headerSize+2] ^= 1; return b }), ErrPackChecksum},
+		{"changed count", edit(func(b []byte) []byte { b[6]++; return b }), ErrPackChecksum},
+		{"truncated", valid[:len(valid)-3], ErrPackChecksum},
+		{"appended", append(bytes.Clone(valid), 0), ErrPackChecksum},
+		{"newer version", edit(func(b []byte) []byte { b[4] = PackVersion + 1; return b }), ErrPackVersion},
+		{"bad magic", edit(func(b []byte) []byte { b[0] = 'X'; return b }), ErrNotPack},
+		{"shorter than the header", valid[:headerSize-1], ErrNotPack},
+		{"empty", nil, ErrNotPack},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadPack(tt.data); !errors.Is(err, tt.want) {
+				t.Errorf("ReadPack = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestOverlayLaterLayersWin(t *testing.T) {
+	base := fstest.MapFS{
+		"a.txt": {Data: []byte("base a")},
+		"b.txt": {Data: []byte("base b")},
+	}
+	first := fstest.MapFS{"a.txt": {Data: []byte("first a")}}
+	second := fstest.MapFS{
+		"a.txt": {Data: []byte("second a")},
+		"c.txt": {Data: []byte("second c")},
+	}
+	overlay := Overlay{base, first, second}
+
+	for name, want := range map[string]string{"a.txt": "second a", "b.txt": "base b", "c.txt": "second c"} {
+		data, err := fs.ReadFile(overlay, name)
+		if err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v, want %q", name, data, err, want)
+		}
+	}
+
+	if _, err := overlay.Open("d.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("opening a file in no layer: %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestMountMergesPackManifest(t *testing.T) {
+	base := fstest.MapFS{
+		ManifestFile:      {Data: []byte(`{"hero": {"type": "image", "path": "images/hero.png"}}`)},
+		"images/hero.png": {Data: []byte("base hero")},
+	}
+	extra := fstest.MapFS{
+		ManifestFile:       {Data: []byte(`{"ghost": {"type": "image", "path": "images/ghost.png", "tags": ["enemy"]}}`)},
+		"images/hero.png":  {Data: []byte("modded hero")},
+		"images/ghost.png": {Data: []byte("ghost")},
+	}
+
+	file := filepath.Join(t.TempDir(), "extra.pak")
+	data := writeTestPack(t, extra, ManifestFile, "images/hero.png", "images/ghost.png")
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys, manifest, err := Mount(base, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"ghost", "hero"}; !slices.Equal(manifest.Names(), want) {
+		t.Errorf("manifest names = %v, want %v", manifest.Names(), want)
+	}
+	if want := []string{"ghost"}; !slices.Equal(manifest.Tagged("enemy"), want) {
+		t.Errorf("enemy assets = %v, want %v", manifest.Tagged("enemy"), want)
+	}
+
+	hero, err := fs.ReadFile(fsys, "images/hero.png")
+	if err != nil || string(hero) != "modded hero" {
+		t.Errorf("hero = %q, %v, want the pack's copy", hero, err)
+	}
+}
+
+func TestMountValidatesManifest(t *testing.T) {
+	base := fstest.MapFS{
+		ManifestFile: {Data: []byte(`{"hero": {"type": "image", "path": "images/hero.png"}}`)},
+	}
+
+	if _, _, err := Mount(base); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Mount with a missing asset = %v, want fs.ErrNotExist", err)
+	}
+}
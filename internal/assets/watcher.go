@@ -0,0 +1,61 @@
+package assets
+
+import (
+	"errors"
+	"io/fs"
+	"time"
+)
+
+// Watcher finds files which have changed by polling their modification times, for reloading assets from disk while
+// the game runs.
+type Watcher struct {
+	fsys   fs.FS
+	mtimes map[string]time.Time
+}
+
+// NewWatcher creates a watcher over every file in the file system, taking their current modification times as the
+// starting point.
+func NewWatcher(fsys fs.FS) (*Watcher, error) {
+	w := &Watcher{fsys: fsys, mtimes: make(map[string]time.Time)}
+	if _, err := w.Poll(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Poll returns the files which have been added or modified since the last poll, in lexical order. Files removed since
+// then are forgotten, so they count as added if they come back.
+func (w *Watcher) Poll() ([]string, error) {
+	changed := make([]string, 0)
+	seen := make(map[string]bool, len(w.mtimes))
+	err := fs.WalkDir(w.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		info, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			// Removed since the directory was read, editors often save by replacing the file
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		seen[name] = true
+		if last, ok := w.mtimes[name]; !ok || !info.ModTime().Equal(last) {
+			w.mtimes[name] = info.ModTime()
+			changed = append(changed, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return changed, err
+	}
+
+	for name := range w.mtimes {
+		if !seen[name] {
+			delete(w.mtimes, name)
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,92 @@
+package assets
+
+import (
+	"io/fs"
+	"slices"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestWatcherPoll(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"images/hero.png": {ModTime: start},
+		"data/items.json": {ModTime: start},
+	}
+
+	w, err := NewWatcher(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	poll := func(want ...string) {
+		t.Helper()
+		changed, err := w.Poll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(changed, want) {
+			t.Errorf("changed = %v, want %v", changed, want)
+		}
+	}
+
+	// Nothing has changed since the watcher started
+	poll()
+
+	fsys["images/hero.png"] = &fstest.MapFile{ModTime: start.Add(time.Second)}
+	fsys["images/ghost.png"] = &fstest.MapFile{ModTime: start}
+	poll("images/ghost.png", "images/hero.png")
+	poll()
+
+	// Removed files are forgotten, and count as new when they come back
+	delete(fsys, "data/items.json")
+	poll()
+	if _, ok := w.mtimes["data/items.json"]; ok {
+		t.Error("removed file is still watched")
+	}
+
+	fsys["data/items.json"] = &fstest.MapFile{ModTime: start}
+	poll("data/items.json")
+}
+
+// vanishingFS is a file system where one file disappears between its directory being read and its info being asked
+// for, the way it does when an editor saves by replacing the file.
+type vanishingFS struct {
+	fstest.MapFS
+	gone string
+}
+
+func (v vanishingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := v.MapFS.ReadDir(name)
+	for i, e := range entries {
+		if e.Name() == v.gone {
+			entries[i] = vanishedEntry{e}
+		}
+	}
+	return entries, err
+}
+
+type vanishedEntry struct {
+	fs.DirEntry
+}
+
+func (e vanishedEntry) Info() (fs.FileInfo, error) {
+	return nil, &fs.PathError{Op: "lstat", Path: e.Name(), Err: fs.ErrNotExist}
+}
+
+func TestWatcherSkipsVanishedFiles(t *testing.T) {
+	fsys := vanishingFS{
+		MapFS: fstest.MapFS{"a.png": {}, "b.png": {}, "c.png": {}},
+		gone:  "b.png",
+	}
+
+	w, err := NewWatcher(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := w.mtimes["b.png"]; ok || len(w.mtimes) != 2 {
+		t.Errorf("watching %v, want a.png and c.png", w.mtimes)
+	}
+}
@@ -0,0 +1,130 @@
+// Package atlas packs many small images into a few large texture pages, so drawing them doesn't keep switching
+// textures.
+package atlas
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hajimehoshi/ebiten/v2"
+	"image"
+	"image/draw"
+)
+
+// ErrTooLarge is returned when adding an image which is bigger than a page.
+var ErrTooLarge = errors.New("image is larger than an atlas page")
+
+// Page is one texture of an atlas.
+type Page struct {
+	Image *ebiten.Image
+
+	// Count is the number of images packed into the page.
+	Count int
+
+	packer *skyline
+
+	// used is the area covered by packed images, not counting padding
+	used int
+}
+
+// Fill is the fraction of the page covered by packed images.
+func (p *Page) Fill() float64 {
+	size := p.Image.Bounds().Size()
+	return float64(p.used) / float64(size.X*size.Y)
+}
+
+// Atlas packs images into pages as they are added, starting a new page whenever an image doesn't fit on the existing
+// ones. Each image is handed back as a sub-image of its page, which draws exactly like a standalone image.
+type Atlas struct {
+	pageSize int
+
+	// padding is the gap left around each image so neighbours don't bleed into each other when drawn scaled
+	padding int
+
+	pages   []*Page
+	regions map[string]*ebiten.Image
+}
+
+// New creates an empty atlas with square pages of the size.
+func New(pageSize, padding int) *Atlas {
+	return &Atlas{
+		pageSize: pageSize,
+		padding:  padding,
+		regions:  make(map[string]*ebiten.Image),
+	}
+}
+
+// Add packs the image under the name, returning its sub-image. Adding a name which is already packed returns the
+// existing sub-image, so the same source is only ever packed once.
+func (a *Atlas) Add(name string, img image.Image) (*ebiten.Image, error) {
+	if region, ok := a.regions[name]; ok {
+		return region, nil
+	}
+
+	size := img.Bounds().Size()
+	w, h := size.X+a.padding, size.Y+a.padding
+	if w > a.pageSize || h > a.pageSize {
+		return nil, fmt.Errorf("%s: %dx%d: %w", name, size.X, size.Y, ErrTooLarge)
+	}
+
+	page, at := a.place(w, h)
+	rect := image.Rectangle{Min: at, Max: at.Add(size)}
+
+	region := page.Image.SubImage(rect).(*ebiten.Image)
+	region.WritePixels(pixels(img))
+
+	page.Count++
+	page.used += size.X * size.Y
+	a.regions[name] = region
+	return region, nil
+}
+
+// place finds room for a w by h rectangle on the first page with space, adding a page if none has any.
+func (a *Atlas) place(w, h int) (*Page, image.Point) {
+	for _, page := range a.pages {
+		if at, ok := page.packer.insert(w, h); ok {
+			return page, at
+		}
+	}
+
+	page := &Page{
+		Image:  ebiten.NewImage(a.pageSize, a.pageSize),
+		packer: newSkyline(a.pageSize, a.pageSize),
+	}
+	a.pages = append(a.pages, page)
+
+	at, _ := page.packer.insert(w, h)
+	return page, at
+}
+
+// Get returns the sub-image packed under the name.
+func (a *Atlas) Get(name string) (*ebiten.Image, bool) {
+	region, ok := a.regions[name]
+	return region, ok
+}
+
+// Pages returns the atlas pages in the order they were created.
+func (a *Atlas) Pages() []*Page {
+	return a.pages
+}
+
+// Replace packs a new image under the name, for when its source has changed. An image the same size as the one
+// already packed is written over it in place, so every sub-image of it shows the new pixels. Otherwise the image is
+// packed again like a new one, and the space the old one took is left unused.
+func (a *Atlas) Replace(name string, img image.Image) (*ebiten.Image, error) {
+	region, ok := a.regions[name]
+	if !ok || region.Bounds().Size() != img.Bounds().Size() {
+		delete(a.regions, name)
+		return a.Add(name, img)
+	}
+
+	region.WritePixels(pixels(img))
+	return region, nil
+}
+
+// pixels returns the pixels of the image for writing straight into a page. Converting to image.RGBA premultiplies
+// alpha the way ebiten wants.
+func pixels(img image.Image) []byte {
+	rgba := image.NewRGBA(image.Rectangle{Max: img.Bounds().Size()})
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return rgba.Pix
+}
@@ -0,0 +1,99 @@
+package atlas
+
+import (
+	"image"
+)
+
+// segment is a stretch of the skyline, w wide with everything below y already packed.
+type segment struct {
+	x, y, w int
+}
+
+// skyline packs rectangles into a fixed size page. It tracks the top edge of the packed area across the page's width
+// and puts each rectangle wherever its top ends up lowest, which keeps the packed area level and the wasted space
+// under it small.
+type skyline struct {
+	width, height int
+	segments      []segment
+}
+
+func newSkyline(width, height int) *skyline {
+	return &skyline{width: width, height: height, segments: []segment{{x: 0, y: 0, w: width}}}
+}
+
+// insert finds room for a w by h rectangle and marks it as packed, returning its top left corner. It returns false if
+// the rectangle doesn't fit anywhere.
+func (s *skyline) insert(w, h int) (image.Point, bool) {
+	best, bestY, bestTop, bestWidth := -1, 0, 0, 0
+	for i := range s.segments {
+		y, ok := s.fit(i, w, h)
+		if !ok {
+			continue
+		}
+
+		// Prefer the lowest top edge, then the narrowest segment so wide gaps are kept for wide rectangles
+		top := y + h
+		if best < 0 || top < bestTop || (top == bestTop && s.segments[i].w < bestWidth) {
+			best, bestY, bestTop, bestWidth = i, y, top, s.segments[i].w
+		}
+	}
+
+	if best < 0 {
+		return image.Point{}, false
+	}
+
+	at := image.Pt(s.segments[best].x, bestY)
+	s.place(best, at, w, h)
+	return at, true
+}
+
+// fit returns the y a w by h rectangle would sit at with its left edge at the start of segment i, which is the
+// highest of the segments under it.
+func (s *skyline) fit(i, w, h int) (int, bool) {
+	x := s.segments[i].x
+	if x+w > s.width {
+		return 0, false
+	}
+
+	y := 0
+	for remaining := w; remaining > 0; i++ {
+		y = max(y, s.segments[i].y)
+		if y+h > s.height {
+			return 0, false
+		}
+		remaining -= s.segments[i].w
+	}
+	return y, true
+}
+
+// place raises the skyline over the rectangle, trimming or removing the segments it covers.
+func (s *skyline) place(i int, at image.Point, w, h int) {
+	s.segments = append(s.segments[:i], append([]segment{{x: at.X, y: at.Y + h, w: w}}, s.segments[i:]...)...)
+
+	end := at.X + w
+	for j := i + 1; j < len(s.segments); {
+		seg := &s.segments[j]
+		if seg.x >= end {
+			break
+		}
+
+		if seg.x+seg.w <= end {
+			s.segments = append(s.segments[:j], s.segments[j+1:]...)
+			continue
+		}
+
+		seg.w -= end - seg.x
+		seg.x = end
+		break
+	}
+
+	// Neighbouring segments at the same height are merged so the skyline stays short
+	for j := 0; j+1 < len(s.segments); {
+		if s.segments[j].y == s.segments[j+1].y {
+			s.segments[j].w += s.segments[j+1].w
+			s.segments = append(s.segments[:j+1], s.segments[j+2:]...)
+			continue
+		}
+		j++
+	}
+}
@@ -0,0 +1,79 @@
+package atlas
+
+import (
+	"image"
+	"math/rand"
+	"testing"
+)
+
+// pack inserts rectangles of random sizes until one doesn't fit, returning where each went.
+func pack(t *testing.T, s *skyline, rng *rand.Rand, maxSize int) []image.Rectangle {
+	t.Helper()
+
+	var packed []image.Rectangle
+	for {
+		w, h := 1+rng.Intn(maxSize), 1+rng.Intn(maxSize)
+		at, ok := s.insert(w, h)
+		if !ok {
+			return packed
+		}
+		packed = append(packed, image.Rectangle{Min: at, Max: at.Add(image.Pt(w, h))})
+	}
+}
+
+func TestSkylineRectsFitAndDontOverlap(t *testing.T) {
+	page := image.Rect(0, 0, 256, 256)
+
+	for seed := int64(0); seed < 20; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		packed := pack(t, newSkyline(page.Dx(), page.Dy()), rng, 48)
+		if len(packed) == 0 {
+			t.Fatalf("seed %d: nothing was packed", seed)
+		}
+
+		for i, r := range packed {
+			if !r.In(page) {
+				t.Errorf("seed %d: rect %v leaves the page", seed, r)
+			}
+
+			for _, other := range packed[:i] {
+				if r.Overlaps(other) {
+					t.Errorf("seed %d: rect %v overlaps %v", seed, r, other)
+				}
+			}
+		}
+	}
+}
+
+func TestSkylineFull(t *testing.T) {
+	s := newSkyline(32, 32)
+
+	want := []image.Point{{0, 0}, {16, 0}, {0, 16}, {16, 16}}
+	for _, w := range want {
+		at, ok := s.insert(16, 16)
+		if !ok || at != w {
+			t.Fatalf("insert = %v, %v, want %v, true", at, ok, w)
+		}
+	}
+
+	// A full page refuses the rectangle, which is what makes the atlas start a new one
+	if at, ok := s.insert(16, 16); ok {
+		t.Fatalf("insert into a full page = %v, want it refused", at)
+	}
+	if at, ok := newSkyline(32, 32).insert(16, 16); !ok || at != (image.Point{}) {
+		t.Errorf("insert into a new page = %v, %v, want the top left corner", at, ok)
+	}
+}
+
+func TestSkylineTooLarge(t *testing.T) {
+	s := newSkyline(32, 32)
+	if _, ok := s.insert(33, 1); ok {
+		t.Error("rect wider than the page was packed")
+	}
+	if _, ok := s.insert(1, 33); ok {
+		t.Error("rect taller than the page was packed")
+	}
+	if _, ok := s.insert(32, 32); !ok {
+		t.Error("rect the size of the page wasn't packed")
+	}
+}
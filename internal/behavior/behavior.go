@@ -0,0 +1,190 @@
+package behavior
+
+import (
+	"fmt"
+)
+
+// Status is the result of ticking a node.
+type Status int
+
+const (
+	// Invalid is the status of a node that has not been ticked since it was last reset.
+	Invalid Status = iota
+	Success
+	Failure
+	Running
+)
+
+func (s Status) String() string {
+	switch s {
+	case Invalid:
+		return "Invalid"
+	case Success:
+		return "Success"
+	case Failure:
+		return "Failure"
+	case Running:
+		return "Running"
+	default:
+		return "Unknown"
+	}
+}
+
+// Blackboard is the memory shared by every node in a tree.
+type Blackboard struct {
+	values map[string]any
+}
+
+func NewBlackboard() *Blackboard {
+	return &Blackboard{values: make(map[string]any)}
+}
+
+func (b *Blackboard) Set(key string, value any) {
+	b.values[key] = value
+}
+
+func (b *Blackboard) Get(key string) (any, bool) {
+	v, ok := b.values[key]
+	return v, ok
+}
+
+func (b *Blackboard) Delete(key string) {
+	delete(b.values, key)
+}
+
+// Float returns the value for key as a float64, or def if it is missing or not a number.
+func (b *Blackboard) Float(key string, def float64) float64 {
+	switch v := b.values[key].(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	default:
+		return def
+	}
+}
+
+// Bool returns the value for key as a bool, or false if it is missing or not a bool.
+func (b *Blackboard) Bool(key string) bool {
+	v, _ := b.values[key].(bool)
+	return v
+}
+
+// Context is passed to every node as the tree is ticked.
+type Context struct {
+	// Agent is whatever the tree is controlling, leaves cast it to the type they expect.
+	Agent any
+
+	Blackboard *Blackboard
+
+	// Tick is the number of times the tree has been ticked.
+	Tick uint64
+}
+
+// Node is a single node of a behavior tree.
+type Node interface {
+	// Tick runs the node for one tick.
+	Tick(ctx *Context) Status
+
+	// Reset returns the node and its children to their initial state, it is called when a running node is interrupted.
+	Reset()
+
+	// Name is the label shown for the node in debug views.
+	Name() string
+
+	// Children are the node's direct children, leaves have none.
+	Children() []Node
+
+	// Last returns the status the node returned the last time it was ticked and the tree tick that happened on.
+	Last() (Status, uint64)
+}
+
+// base holds the bookkeeping shared by every node.
+type base struct {
+	name       string
+	lastStatus Status
+	lastTick   uint64
+}
+
+func (b *base) Name() string {
+	return b.name
+}
+
+func (b *base) Last() (Status, uint64) {
+	return b.lastStatus, b.lastTick
+}
+
+// record stores a tick result for debug views and passes it through.
+func (b *base) record(ctx *Context, s Status) Status {
+	b.lastStatus = s
+	b.lastTick = ctx.Tick
+	return s
+}
+
+// Tree is an instance of a behavior tree, along with its blackboard, bound to a single agent.
+type Tree struct {
+	Root       Node
+	Blackboard *Blackboard
+
+	tick uint64
+}
+
+func NewTree(root Node) *Tree {
+	return &Tree{Root: root, Blackboard: NewBlackboard()}
+}
+
+// Tick runs the tree once for the agent.
+func (t *Tree) Tick(agent any) Status {
+	t.tick++
+	ctx := &Context{Agent: agent, Blackboard: t.Blackboard, Tick: t.tick}
+
+	s := t.Root.Tick(ctx)
+	if s != Running {
+		t.Root.Reset()
+	}
+	return s
+}
+
+// CurrentTick is the number of times the tree has been ticked, nodes whose last tick matches it ran this tick.
+func (t *Tree) CurrentTick() uint64 {
+	return t.tick
+}
+
+// Walk visits every node in the tree depth first, along with its depth.
+func (t *Tree) Walk(fn func(n Node, depth int)) {
+	var walk func(n Node, depth int)
+	walk = func(n Node, depth int) {
+		fn(n, depth)
+		for _, c := range n.Children() {
+			walk(c, depth+1)
+		}
+	}
+	walk(t.Root, 0)
+}
+
+// RunningPath returns the names of the chain of nodes that are running as of the last tick, from the root down.
+func (t *Tree) RunningPath() []string {
+	path := make([]string, 0)
+	n := t.Root
+	for n != nil {
+		status, tick := n.Last()
+		if status != Running || tick != t.tick {
+			break
+		}
+		path = append(path, n.Name())
+
+		var next Node
+		for _, c := range n.Children() {
+			if s, ct := c.Last(); s == Running && ct == t.tick {
+				next = c
+				break
+			}
+		}
+		n = next
+	}
+	return path
+}
+
+func (t *Tree) String() string {
+	return fmt.Sprintf("Tree(tick %d, running %v)", t.tick, t.RunningPath())
+}
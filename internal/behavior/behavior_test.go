@@ -0,0 +1,370 @@
+package behavior
+
+import (
+	"strings"
+	"testing"
+)
+
+// scripted is a leaf returning a fixed list of statuses in turn, repeating the last once the list runs out. Resets don't
+// rewind it, so the list is what the leaf sees of the world tick by tick. It counts how often it is ticked and reset.
+type scripted struct {
+	base
+	statuses []Status
+	next     int
+
+	ticks, resets int
+}
+
+func newScripted(name string, statuses ...Status) *scripted {
+	return &scripted{base: base{name: name}, statuses: statuses}
+}
+
+func (n *scripted) Tick(ctx *Context) Status {
+	n.ticks++
+	s := n.statuses[min(n.next, len(n.statuses)-1)]
+	n.next++
+	return n.record(ctx, s)
+}
+
+func (n *scripted) Reset() {
+	n.resets++
+}
+
+func (n *scripted) Children() []Node {
+	return nil
+}
+
+// tickAll ticks the tree once for each wanted status, failing on the first which doesn't match.
+func tickAll(t *testing.T, tree *Tree, want ...Status) {
+	t.Helper()
+	for i, w := range want {
+		if got := tree.Tick(nil); got != w {
+			t.Fatalf("tick %d: got %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestSequenceResumesRunningChild(t *testing.T) {
+	first := newScripted("first", Success)
+	second := newScripted("second", Running, Running, Success)
+	third := newScripted("third", Success)
+	tree := NewTree(NewSequence("seq", false, first, second, third))
+
+	tickAll(t, tree, Running, Running, Success)
+
+	if first.ticks != 1 {
+		t.Errorf("first ticked %d times, want 1: a running sequence resumes where it left off", first.ticks)
+	}
+	if third.ticks != 1 {
+		t.Errorf("third ticked %d times, want 1", third.ticks)
+	}
+}
+
+func TestSequenceStopsAtFailure(t *testing.T) {
+	first := newScripted("first", Success)
+	second := newScripted("second", Failure)
+	third := newScripted("third", Success)
+	tree := NewTree(NewSequence("seq", false, first, second, third))
+
+	tickAll(t, tree, Failure, Failure)
+
+	if third.ticks != 0 {
+		t.Errorf("third ticked %d times, want 0", third.ticks)
+	}
+	if first.ticks != 2 {
+		t.Errorf("first ticked %d times, want 2: a failed sequence starts over", first.ticks)
+	}
+}
+
+func TestReactiveSequenceInterruptsRunningChild(t *testing.T) {
+	guard := newScripted("guard", Success, Success, Failure)
+	action := newScripted("action", Running)
+	tree := NewTree(NewSequence("seq", true, guard, action))
+
+	tickAll(t, tree, Running, Running, Failure)
+
+	if guard.ticks != 3 {
+		t.Errorf("guard ticked %d times, want 3: a reactive sequence checks it every tick", guard.ticks)
+	}
+	if action.ticks != 2 {
+		t.Errorf("action ticked %d times, want 2", action.ticks)
+	}
+	if action.resets == 0 {
+		t.Error("action was never reset after being interrupted")
+	}
+}
+
+func TestSelectorPicksFirstSuccess(t *testing.T) {
+	first := newScripted("first", Failure)
+	second := newScripted("second", Success)
+	third := newScripted("third", Success)
+	tree := NewTree(NewSelector("sel", false, first, second, third))
+
+	tickAll(t, tree, Success)
+
+	if third.ticks != 0 {
+		t.Errorf("third ticked %d times, want 0", third.ticks)
+	}
+}
+
+func TestSelectorFailsWhenEveryChildFails(t *testing.T) {
+	tree := NewTree(NewSelector("sel", false, newScripted("first", Failure), newScripted("second", Failure)))
+	tickAll(t, tree, Failure)
+}
+
+func TestSelectorResumesRunningChild(t *testing.T) {
+	first := newScripted("first", Failure)
+	second := newScripted("second", Running, Running, Success)
+	tree := NewTree(NewSelector("sel", false, first, second))
+
+	tickAll(t, tree, Running, Running, Success)
+
+	if first.ticks != 1 {
+		t.Errorf("first ticked %d times, want 1", first.ticks)
+	}
+}
+
+func TestReactiveSelectorLetsHigherPriorityTakeOver(t *testing.T) {
+	urgent := newScripted("urgent", Failure, Failure, Running)
+	idle := newScripted("idle", Running)
+	tree := NewTree(NewSelector("sel", true, urgent, idle))
+
+	tickAll(t, tree, Running, Running, Running)
+
+	if idle.ticks != 2 {
+		t.Errorf("idle ticked %d times, want 2", idle.ticks)
+	}
+	if idle.resets == 0 {
+		t.Error("idle was never reset when urgent took over")
+	}
+	if path := tree.RunningPath(); len(path) != 2 || path[1] != "urgent" {
+		t.Errorf("running path %v, want [sel urgent]", path)
+	}
+}
+
+func TestDecorators(t *testing.T) {
+	tests := []struct {
+		name string
+		node func() Node
+		want []Status
+	}{
+		{
+			name: "inverter swaps success for failure",
+			node: func() Node { return NewInverter("inv", newScripted("leaf", Success)) },
+			want: []Status{Failure},
+		},
+		{
+			name: "inverter swaps failure for success",
+			node: func() Node { return NewInverter("inv", newScripted("leaf", Failure)) },
+			want: []Status{Success},
+		},
+		{
+			name: "inverter passes running through",
+			node: func() Node { return NewInverter("inv", newScripted("leaf", Running, Failure)) },
+			want: []Status{Running, Success},
+		},
+		{
+			name: "succeeder hides failure",
+			node: func() Node { return NewSucceeder("ok", newScripted("leaf", Running, Failure)) },
+			want: []Status{Running, Success},
+		},
+		{
+			name: "repeater runs its child count times",
+			node: func() Node { return NewRepeater("rep", 3, newScripted("leaf", Success)) },
+			want: []Status{Running, Running, Success},
+		},
+		{
+			name: "repeater fails with its child",
+			node: func() Node { return NewRepeater("rep", 3, newScripted("leaf", Success, Failure)) },
+			want: []Status{Running, Failure},
+		},
+		{
+			name: "until fail succeeds once its child fails",
+			node: func() Node {
+				return NewUntilFail("until", NewSequence("seq", false, newScripted("leaf", Success, Success, Failure)))
+			},
+			want: []Status{Running, Running, Success},
+		},
+		{
+			name: "cooldown fails until its ticks have passed",
+			node: func() Node { return NewCooldown("cd", 3, newScripted("leaf", Success)) },
+			want: []Status{Success, Failure, Failure, Success},
+		},
+		{
+			name: "cooldown starts once a running child finishes",
+			node: func() Node { return NewCooldown("cd", 2, newScripted("leaf", Running, Success)) },
+			want: []Status{Running, Success, Failure, Success},
+		},
+		{
+			name: "wait runs for its ticks",
+			node: func() Node { return NewWait("wait", 3) },
+			want: []Status{Running, Running, Success},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tickAll(t, NewTree(tt.node()), tt.want...)
+		})
+	}
+}
+
+func TestParallel(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		children  [][]Status
+		want      []Status
+	}{
+		{
+			name:     "every child has to succeed by default",
+			children: [][]Status{{Success}, {Running, Success}, {Success}},
+			want:     []Status{Running, Success},
+		},
+		{
+			name:     "one failure fails it by default",
+			children: [][]Status{{Success}, {Failure}, {Running}},
+			want:     []Status{Failure},
+		},
+		{
+			name:      "one success is enough with a threshold of one",
+			threshold: 1,
+			children:  [][]Status{{Failure}, {Failure}, {Running, Success}},
+			want:      []Status{Running, Success},
+		},
+		{
+			name:      "fails once the threshold can't be reached",
+			threshold: 1,
+			children:  [][]Status{{Failure}, {Failure}, {Running, Failure}},
+			want:      []Status{Running, Failure},
+		},
+		{
+			name:      "two of three",
+			threshold: 2,
+			children:  [][]Status{{Failure}, {Running, Running, Success}, {Success}},
+			want:      []Status{Running, Running, Success},
+		},
+		{
+			name:      "two of three can't survive two failures",
+			threshold: 2,
+			children:  [][]Status{{Failure}, {Failure}, {Running}},
+			want:      []Status{Failure},
+		},
+		{
+			name:      "a threshold above the child count needs every child",
+			threshold: 5,
+			children:  [][]Status{{Success}, {Running, Success}},
+			want:      []Status{Running, Success},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			children := make([]Node, len(tt.children))
+			for i, statuses := range tt.children {
+				children[i] = newScripted("leaf", statuses...)
+			}
+			tickAll(t, NewTree(NewParallel("par", tt.threshold, children...)), tt.want...)
+		})
+	}
+}
+
+func TestParallelKeepsFinishedResults(t *testing.T) {
+	done := newScripted("done", Success)
+	slow := newScripted("slow", Running, Running, Success)
+	tickAll(t, NewTree(NewParallel("par", 0, done, slow)), Running, Running, Success)
+
+	if done.ticks != 1 {
+		t.Errorf("finished child ticked %d times, want once", done.ticks)
+	}
+}
+
+func TestRegistryBuild(t *testing.T) {
+	r := NewRegistry(60)
+	r.RegisterAction("attack", func(*Context, Params) Status { return Success })
+	r.RegisterCondition("sees_player", func(*Context, Params) bool { return true })
+
+	specs, err := LoadSpecs([]byte(`{
+		"brute": {"type": "selector", "children": [
+			{"type": "sequence", "children": [
+				{"type": "condition", "action": "sees_player"},
+				{"type": "cooldown", "params": {"seconds": 1}, "children": [{"type": "action", "action": "attack"}]}
+			]},
+			{"type": "wait", "params": {"ticks": 10}}
+		]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := r.Build(specs["brute"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	tickAll(t, tree, Success)
+}
+
+func TestRegistryRejects(t *testing.T) {
+	leaf := &Spec{Type: "action", Action: "set"}
+	tests := []struct {
+		name string
+		spec *Spec
+		want string
+	}{
+		{"unknown action", &Spec{Type: "action", Action: "fly"}, `unknown action "fly"`},
+		{"unknown condition", &Spec{Type: "condition", Action: "is_flying"}, `unknown condition "is_flying"`},
+		{"unknown node type", &Spec{Type: "loop"}, `unknown node type "loop"`},
+		{"decorator without a child", &Spec{Type: "inverter"}, "needs exactly one child, has 0"},
+		{"decorator with two children", &Spec{Type: "cooldown", Children: []*Spec{leaf, leaf}}, "needs exactly one child, has 2"},
+		{
+			"bad node deep in the tree",
+			&Spec{Type: "sequence", Children: []*Spec{leaf, {Type: "succeeder", Children: []*Spec{{Type: "action", Action: "fly"}}}}},
+			`unknown action "fly"`,
+		},
+	}
+
+	r := NewRegistry(60)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.Validate(tt.spec)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate = %v, want an error containing %q", err, tt.want)
+			}
+
+			if tree, err := r.Build(tt.spec); err == nil || tree != nil {
+				t.Errorf("Build = %v, %v, want an error", tree, err)
+			}
+		})
+	}
+}
+
+func TestRegistryTicks(t *testing.T) {
+	tests := []struct {
+		params Params
+		want   uint64
+	}{
+		{Params{}, 0},
+		{Params{"ticks": 5.0}, 5},
+		{Params{"seconds": 0.5}, 30},
+		{Params{"seconds": 0.51}, 31},
+		{Params{"seconds": 2.0, "ticks": 7.0}, 7},
+		{Params{"seconds": 1.0, "ticks": -1.0}, 60},
+		{Params{"seconds": "soon"}, 0},
+	}
+
+	r := NewRegistry(60)
+	for _, tt := range tests {
+		if got := r.ticks(tt.params); got != tt.want {
+			t.Errorf("ticks(%v) = %d, want %d", tt.params, got, tt.want)
+		}
+	}
+
+	// Built nodes take their durations from the same parameters
+	node, err := r.build(&Spec{Type: "wait", Params: Params{"seconds": 0.25}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wait := node.(*Wait); wait.Ticks != 15 {
+		t.Errorf("wait of a quarter second is %d ticks, want 15", wait.Ticks)
+	}
+}
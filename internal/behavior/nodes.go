@@ -0,0 +1,391 @@
+package behavior
+
+// Sequence runs its children in order until one fails. It succeeds once every child has succeeded, and picks up from
+// a running child on the next tick rather than starting over. A Reactive sequence instead starts over every tick, so
+// conditions ahead of a running child are checked again and can interrupt it.
+type Sequence struct {
+	base
+	children []Node
+	current  int
+
+	Reactive bool
+}
+
+func NewSequence(name string, reactive bool, children ...Node) *Sequence {
+	return &Sequence{base: base{name: name}, children: children, Reactive: reactive}
+}
+
+func (n *Sequence) Tick(ctx *Context) Status {
+	start := n.current
+	if n.Reactive {
+		start = 0
+	}
+
+	for i := start; i < len(n.children); i++ {
+		switch n.children[i].Tick(ctx) {
+		case Running:
+			n.interrupt(i)
+			return n.record(ctx, Running)
+		case Failure:
+			n.Reset()
+			return n.record(ctx, Failure)
+		}
+	}
+
+	n.Reset()
+	return n.record(ctx, Success)
+}
+
+// interrupt makes child i the running child, resetting whichever later child was running before it.
+func (n *Sequence) interrupt(i int) {
+	if n.current > i {
+		n.children[n.current].Reset()
+	}
+	n.current = i
+}
+
+func (n *Sequence) Reset() {
+	n.current = 0
+	for _, c := range n.children {
+		c.Reset()
+	}
+}
+
+func (n *Sequence) Children() []Node {
+	return n.children
+}
+
+// Selector runs its children in order until one succeeds, it fails only once every child has failed. Like Sequence it
+// resumes a running child, unless it is Reactive, in which case higher priority children are tried again every tick
+// and take over from a running lower priority child as soon as they stop failing.
+type Selector struct {
+	base
+	children []Node
+	current  int
+
+	Reactive bool
+}
+
+func NewSelector(name string, reactive bool, children ...Node) *Selector {
+	return &Selector{base: base{name: name}, children: children, Reactive: reactive}
+}
+
+func (n *Selector) Tick(ctx *Context) Status {
+	start := n.current
+	if n.Reactive {
+		start = 0
+	}
+
+	for i := start; i < len(n.children); i++ {
+		switch n.children[i].Tick(ctx) {
+		case Running:
+			n.interrupt(i)
+			return n.record(ctx, Running)
+		case Success:
+			n.Reset()
+			return n.record(ctx, Success)
+		}
+	}
+
+	n.Reset()
+	return n.record(ctx, Failure)
+}
+
+// interrupt makes child i the running child, resetting whichever later child was running before it.
+func (n *Selector) interrupt(i int) {
+	if n.current > i {
+		n.children[n.current].Reset()
+	}
+	n.current = i
+}
+
+func (n *Selector) Reset() {
+	n.current = 0
+	for _, c := range n.children {
+		c.Reset()
+	}
+}
+
+func (n *Selector) Children() []Node {
+	return n.children
+}
+
+// Parallel ticks all of its children every tick. It succeeds once SuccessThreshold children have succeeded and fails
+// as soon as enough have failed that the threshold can no longer be met. A threshold of zero requires every child.
+type Parallel struct {
+	base
+	children []Node
+	results  []Status
+
+	SuccessThreshold int
+}
+
+func NewParallel(name string, successThreshold int, children ...Node) *Parallel {
+	return &Parallel{
+		base:             base{name: name},
+		children:         children,
+		results:          make([]Status, len(children)),
+		SuccessThreshold: successThreshold,
+	}
+}
+
+func (n *Parallel) Tick(ctx *Context) Status {
+	threshold := n.SuccessThreshold
+	if threshold <= 0 || threshold > len(n.children) {
+		threshold = len(n.children)
+	}
+
+	successes, failures := 0, 0
+	for i, c := range n.children {
+		// Children which have finished keep their result until the whole node finishes
+		if n.results[i] == Success || n.results[i] == Failure {
+			if n.results[i] == Success {
+				successes++
+			} else {
+				failures++
+			}
+			continue
+		}
+
+		n.results[i] = c.Tick(ctx)
+		switch n.results[i] {
+		case Success:
+			successes++
+		case Failure:
+			failures++
+		}
+	}
+
+	switch {
+	case successes >= threshold:
+		n.Reset()
+		return n.record(ctx, Success)
+	case failures > len(n.children)-threshold:
+		n.Reset()
+		return n.record(ctx, Failure)
+	default:
+		return n.record(ctx, Running)
+	}
+}
+
+func (n *Parallel) Reset() {
+	for i, c := range n.children {
+		n.results[i] = Invalid
+		c.Reset()
+	}
+}
+
+func (n *Parallel) Children() []Node {
+	return n.children
+}
+
+// decorator is the shared part of every node with exactly one child.
+type decorator struct {
+	base
+	child Node
+}
+
+func (d *decorator) Reset() {
+	d.child.Reset()
+}
+
+func (d *decorator) Children() []Node {
+	return []Node{d.child}
+}
+
+// Inverter swaps success and failure of its child.
+type Inverter struct {
+	decorator
+}
+
+func NewInverter(name string, child Node) *Inverter {
+	return &Inverter{decorator{base: base{name: name}, child: child}}
+}
+
+func (n *Inverter) Tick(ctx *Context) Status {
+	switch n.child.Tick(ctx) {
+	case Success:
+		return n.record(ctx, Failure)
+	case Failure:
+		return n.record(ctx, Success)
+	default:
+		return n.record(ctx, Running)
+	}
+}
+
+// Succeeder succeeds whenever its child finishes, whatever the result.
+type Succeeder struct {
+	decorator
+}
+
+func NewSucceeder(name string, child Node) *Succeeder {
+	return &Succeeder{decorator{base: base{name: name}, child: child}}
+}
+
+func (n *Succeeder) Tick(ctx *Context) Status {
+	if n.child.Tick(ctx) == Running {
+		return n.record(ctx, Running)
+	}
+	return n.record(ctx, Success)
+}
+
+// Repeater runs its child again each time it succeeds, up to Count times. It fails if the child fails. A Count of zero
+// or less repeats forever.
+type Repeater struct {
+	decorator
+	Count int
+	done  int
+}
+
+func NewRepeater(name string, count int, child Node) *Repeater {
+	return &Repeater{decorator: decorator{base: base{name: name}, child: child}, Count: count}
+}
+
+func (n *Repeater) Tick(ctx *Context) Status {
+	switch n.child.Tick(ctx) {
+	case Running:
+		return n.record(ctx, Running)
+	case Failure:
+		n.Reset()
+		return n.record(ctx, Failure)
+	}
+
+	n.done++
+	n.child.Reset()
+	if n.Count > 0 && n.done >= n.Count {
+		n.Reset()
+		return n.record(ctx, Success)
+	}
+
+	// Pick the child back up next tick rather than looping here, so a child which succeeds instantly cannot hang
+	return n.record(ctx, Running)
+}
+
+func (n *Repeater) Reset() {
+	n.done = 0
+	n.child.Reset()
+}
+
+// UntilFail runs its child repeatedly until it fails, then succeeds.
+type UntilFail struct {
+	decorator
+}
+
+func NewUntilFail(name string, child Node) *UntilFail {
+	return &UntilFail{decorator{base: base{name: name}, child: child}}
+}
+
+func (n *UntilFail) Tick(ctx *Context) Status {
+	switch n.child.Tick(ctx) {
+	case Failure:
+		n.Reset()
+		return n.record(ctx, Success)
+	case Success:
+		n.child.Reset()
+	}
+	return n.record(ctx, Running)
+}
+
+// Cooldown fails without ticking its child until Ticks ticks have passed since the child last finished.
+type Cooldown struct {
+	decorator
+	Ticks uint64
+
+	// readyAt is the tree tick from which the child may run again.
+	readyAt uint64
+}
+
+func NewCooldown(name string, ticks uint64, child Node) *Cooldown {
+	return &Cooldown{decorator: decorator{base: base{name: name}, child: child}, Ticks: ticks}
+}
+
+func (n *Cooldown) Tick(ctx *Context) Status {
+	if ctx.Tick < n.readyAt {
+		return n.record(ctx, Failure)
+	}
+
+	s := n.child.Tick(ctx)
+	if s != Running {
+		n.readyAt = ctx.Tick + n.Ticks
+	}
+	return n.record(ctx, s)
+}
+
+// Wait runs for Ticks ticks and then succeeds.
+type Wait struct {
+	base
+	Ticks   uint64
+	elapsed uint64
+}
+
+func NewWait(name string, ticks uint64) *Wait {
+	return &Wait{base: base{name: name}, Ticks: ticks}
+}
+
+func (n *Wait) Tick(ctx *Context) Status {
+	n.elapsed++
+	if n.elapsed >= n.Ticks {
+		n.elapsed = 0
+		return n.record(ctx, Success)
+	}
+	return n.record(ctx, Running)
+}
+
+func (n *Wait) Reset() {
+	n.elapsed = 0
+}
+
+func (n *Wait) Children() []Node {
+	return nil
+}
+
+// ActionFunc is the behavior of an Action leaf.
+type ActionFunc func(ctx *Context, params Params) Status
+
+// ConditionFunc is the test of a Condition leaf.
+type ConditionFunc func(ctx *Context, params Params) bool
+
+// Action is a leaf which runs a function registered with the game.
+type Action struct {
+	base
+	fn     ActionFunc
+	params Params
+}
+
+func NewAction(name string, fn ActionFunc, params Params) *Action {
+	return &Action{base: base{name: name}, fn: fn, params: params}
+}
+
+func (n *Action) Tick(ctx *Context) Status {
+	return n.record(ctx, n.fn(ctx, n.params))
+}
+
+func (n *Action) Reset() {}
+
+func (n *Action) Children() []Node {
+	return nil
+}
+
+// Condition is a leaf which succeeds when its test passes and fails otherwise, it never runs across ticks.
+type Condition struct {
+	base
+	fn     ConditionFunc
+	params Params
+}
+
+func NewCondition(name string, fn ConditionFunc, params Params) *Condition {
+	return &Condition{base: base{name: name}, fn: fn, params: params}
+}
+
+func (n *Condition) Tick(ctx *Context) Status {
+	if n.fn(ctx, n.params) {
+		return n.record(ctx, Success)
+	}
+	return n.record(ctx, Failure)
+}
+
+func (n *Condition) Reset() {}
+
+func (n *Condition) Children() []Node {
+	return nil
+}
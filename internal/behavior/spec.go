@@ -0,0 +1,216 @@
+package behavior
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Params are the parameters given to a node in a tree definition.
+type Params map[string]any
+
+// Float returns the parameter as a float64, or def if it is missing or not a number.
+func (p Params) Float(key string, def float64) float64 {
+	if v, ok := p[key].(float64); ok {
+		return v
+	}
+	return def
+}
+
+// Int returns the parameter as an int, or def if it is missing or not a number.
+func (p Params) Int(key string, def int) int {
+	if v, ok := p[key].(float64); ok {
+		return int(v)
+	}
+	return def
+}
+
+// String returns the parameter as a string, or def if it is missing or not a string.
+func (p Params) String(key string, def string) string {
+	if v, ok := p[key].(string); ok {
+		return v
+	}
+	return def
+}
+
+// Bool returns the parameter as a bool, or def if it is missing or not a bool.
+func (p Params) Bool(key string, def bool) bool {
+	if v, ok := p[key].(bool); ok {
+		return v
+	}
+	return def
+}
+
+// Spec is the data definition of a node and its children, as loaded from a tree file. A Spec can be built into any
+// number of independent trees.
+//
+// Composite types are "sequence", "selector" and "parallel". Decorator types, which take exactly one child, are
+// "inverter", "succeeder", "repeater", "until_fail" and "cooldown". Leaf types are "wait", "action" and "condition",
+// where the action and condition leaves name a function registered with the Registry.
+type Spec struct {
+	Type     string  `json:"type"`
+	Name     string  `json:"name"`
+	Action   string  `json:"action"`
+	Params   Params  `json:"params"`
+	Children []*Spec `json:"children"`
+}
+
+// LoadSpecs parses a JSON object of tree definitions keyed by name.
+func LoadSpecs(data []byte) (map[string]*Spec, error) {
+	specs := make(map[string]*Spec)
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return nil, fmt.Errorf("behavior: failed to parse trees: %w", err)
+	}
+	return specs, nil
+}
+
+// Registry maps the names used by action and condition leaves to the functions that implement them.
+type Registry struct {
+	// TicksPerSecond converts the "seconds" parameter of time based nodes into ticks.
+	TicksPerSecond float64
+
+	actions    map[string]ActionFunc
+	conditions map[string]ConditionFunc
+}
+
+// NewRegistry creates a registry with the built in blackboard leaves already registered. The "set" action stores the
+// "value" parameter under the "key" parameter and always succeeds, the "is" condition passes when the value stored
+// under "key" equals "value".
+func NewRegistry(ticksPerSecond float64) *Registry {
+	r := &Registry{
+		TicksPerSecond: ticksPerSecond,
+		actions:        make(map[string]ActionFunc),
+		conditions:     make(map[string]ConditionFunc),
+	}
+
+	r.RegisterAction("set", func(ctx *Context, params Params) Status {
+		ctx.Blackboard.Set(params.String("key", ""), params["value"])
+		return Success
+	})
+
+	r.RegisterCondition("is", func(ctx *Context, params Params) bool {
+		want := params["value"]
+		switch want.(type) {
+		case []any, map[string]any:
+			// Lists and objects from the data file cannot be compared
+			return false
+		}
+
+		v, ok := ctx.Blackboard.Get(params.String("key", ""))
+		return ok && v == want
+	})
+
+	return r
+}
+
+func (r *Registry) RegisterAction(name string, fn ActionFunc) {
+	r.actions[name] = fn
+}
+
+func (r *Registry) RegisterCondition(name string, fn ConditionFunc) {
+	r.conditions[name] = fn
+}
+
+// Build creates a new tree from a definition.
+func (r *Registry) Build(spec *Spec) (*Tree, error) {
+	root, err := r.build(spec)
+	if err != nil {
+		return nil, err
+	}
+	return NewTree(root), nil
+}
+
+// Validate checks a definition can be built without building it.
+func (r *Registry) Validate(spec *Spec) error {
+	_, err := r.build(spec)
+	return err
+}
+
+func (r *Registry) build(spec *Spec) (Node, error) {
+	name := spec.Name
+	if name == "" {
+		name = spec.Type
+		if spec.Action != "" {
+			name = spec.Action
+		}
+	}
+
+	children := make([]Node, 0, len(spec.Children))
+	for _, c := range spec.Children {
+		child, err := r.build(c)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+
+	only := func() (Node, error) {
+		if len(children) != 1 {
+			return nil, fmt.Errorf("behavior: %s %q needs exactly one child, has %d", spec.Type, name, len(children))
+		}
+		return children[0], nil
+	}
+
+	switch spec.Type {
+	case "sequence":
+		return NewSequence(name, spec.Params.Bool("reactive", false), children...), nil
+	case "selector":
+		return NewSelector(name, spec.Params.Bool("reactive", false), children...), nil
+	case "parallel":
+		return NewParallel(name, spec.Params.Int("success_threshold", 0), children...), nil
+	case "inverter":
+		child, err := only()
+		if err != nil {
+			return nil, err
+		}
+		return NewInverter(name, child), nil
+	case "succeeder":
+		child, err := only()
+		if err != nil {
+			return nil, err
+		}
+		return NewSucceeder(name, child), nil
+	case "repeater":
+		child, err := only()
+		if err != nil {
+			return nil, err
+		}
+		return NewRepeater(name, spec.Params.Int("count", 0), child), nil
+	case "until_fail":
+		child, err := only()
+		if err != nil {
+			return nil, err
+		}
+		return NewUntilFail(name, child), nil
+	case "cooldown":
+		child, err := only()
+		if err != nil {
+			return nil, err
+		}
+		return NewCooldown(name, r.ticks(spec.Params), child), nil
+	case "wait":
+		return NewWait(name, r.ticks(spec.Params)), nil
+	case "action":
+		fn, ok := r.actions[spec.Action]
+		if !ok {
+			return nil, fmt.Errorf("behavior: unknown action %q", spec.Action)
+		}
+		return NewAction(name, fn, spec.Params), nil
+	case "condition":
+		fn, ok := r.conditions[spec.Action]
+		if !ok {
+			return nil, fmt.Errorf("behavior: unknown condition %q", spec.Action)
+		}
+		return NewCondition(name, fn, spec.Params), nil
+	default:
+		return nil, fmt.Errorf("behavior: unknown node type %q", spec.Type)
+	}
+}
+
+// ticks reads a duration from either a "ticks" or a "seconds" parameter.
+func (r *Registry) ticks(params Params) uint64 {
+	if ticks := params.Int("ticks", -1); ticks >= 0 {
+		return uint64(ticks)
+	}
+	return uint64(math.Round(params.Float("seconds", 0) * r.TicksPerSecond))
+}
@@ -0,0 +1,175 @@
+package game
+
+import (
+	"dungeon/internal/animation"
+	"fmt"
+)
+
+// Parameters and triggers the character animators are driven by
+const (
+	animMoving  = "moving"
+	animDashing = "dashing"
+	animCasting = "casting"
+	animDead    = "dead"
+	animHurt    = "hurt"
+)
+
+// SpriteDef is the data-driven definition of a character sprite.
+type SpriteDef struct {
+	// Name is the key the definition was loaded under.
+	Name string `json:"-"`
+
+	// Sheet is the asset name of the Aseprite export the sprite's clips are loaded from.
+	Sheet string `json:"sheet"`
+
+	// Directions is 4 for sprites drawn facing front, back, left and right, or 8 to add the diagonals.
+	Directions int `json:"directions"`
+
+	// Mirror fills in any direction the sheet has no tags for by flipping its mirror image.
+	Mirror bool `json:"mirror"`
+
+	// sheet is the sheet Sheet was loaded from when the definitions were loaded.
+	sheet *animation.Sheet
+}
+
+func (d *SpriteDef) setName(name string) { d.Name = name }
+
+// characterStates are the animation states every character sprite has, along with the states one-shots move on to,
+// which are part of the game rather than the sprite sheet.
+var characterStates = []struct {
+	name string
+	next string
+}{
+	{name: "idle"},
+	{name: "walk"},
+	{name: "dash"},
+	{name: "cast"},
+	{name: "hurt", next: "idle"},
+	{name: "die"},
+}
+
+// tagDirections are the direction suffixes of a state's tags. A tag without a suffix is used for every direction.
+var tagDirections = []struct {
+	suffix    string
+	direction animation.Direction
+}{
+	{"", animation.Front},
+	{"_front", animation.Front},
+	{"_back", animation.Back},
+	{"_left", animation.Left},
+	{"_right", animation.Right},
+	{"_front_left", animation.FrontLeft},
+	{"_front_right", animation.FrontRight},
+	{"_back_left", animation.BackLeft},
+	{"_back_right", animation.BackRight},
+}
+
+// spriteClips returns the clips for a state from the sprite's sheet. A state's tags are named after it, either alone
+// or with a direction suffix, so "walk_front" and "walk_right" make up the walk state.
+func spriteClips(sheet *animation.Sheet, state string) (map[animation.Direction]*animation.Clip, error) {
+	clips := make(map[animation.Direction]*animation.Clip)
+	for _, td := range tagDirections {
+		if _, ok := sheet.Tag(state + td.suffix); !ok {
+			continue
+		}
+
+		clip, err := sheet.Clip(state + td.suffix)
+		if err != nil {
+			return nil, err
+		}
+		clips[td.direction] = clip
+	}
+
+	if clips[animation.Front] == nil {
+		return nil, fmt.Errorf("no front facing tag for the %s state", state)
+	}
+	return clips, nil
+}
+
+// spriteStates returns the animation states for a sprite, built from the tags on its sheet.
+func spriteStates(def *SpriteDef) ([]*animation.State, error) {
+	states := make([]*animation.State, 0, len(characterStates))
+	for _, cs := range characterStates {
+		clips, err := spriteClips(def.sheet, cs.name)
+		if err != nil {
+			return nil, fmt.Errorf("sprite %s: %w", def.Name, err)
+		}
+		states = append(states, &animation.State{Name: cs.name, Clips: clips, Mirror: def.Mirror, Next: cs.next})
+	}
+	return states, nil
+}
+
+// spriteSet returns the image set an object with the sprite is created from. Only the front facing idle image is
+// needed, the object is sized from it and the animator picks every frame drawn.
+func spriteSet(def *SpriteDef) (map[Orientation]*animation.Image, error) {
+	clips, err := spriteClips(def.sheet, "idle")
+	if err != nil {
+		return nil, fmt.Errorf("sprite %s: %w", def.Name, err)
+	}
+	return map[Orientation]*animation.Image{Front: clips[animation.Front].Image}, nil
+}
+
+// newCharacterAnimator creates an animator for a character with the sprite. Dying and getting hurt interrupt anything,
+// otherwise the character casts, dashes, walks or stands idle, in that order of priority.
+func newCharacterAnimator(sprite *SpriteDef) (*animation.Animator, error) {
+	states, err := spriteStates(sprite)
+	if err != nil {
+		return nil, err
+	}
+
+	a := animation.NewAnimator("idle", states...)
+	a.Eight = sprite.Directions == 8
+	a.AddTransition(animation.Transition{To: "die", When: animation.Is(animDead), Interrupts: true})
+	a.AddTransition(animation.Transition{
+		To: "hurt",
+		When: func(a *animation.Animator) bool {
+			return animation.Triggered(animHurt)(a) && animation.Not(animDead)(a)
+		},
+		Interrupts: true,
+	})
+
+	for _, from := range []string{"idle", "walk", "dash", "cast", "hurt"} {
+		a.AddTransition(animation.Transition{From: from, To: "dash", When: animation.Is(animDashing)})
+		a.AddTransition(animation.Transition{From: from, To: "cast", When: animation.Is(animCasting)})
+	}
+
+	a.AddTransition(animation.Transition{From: "dash", To: "idle", When: animation.Not(animDashing)})
+	a.AddTransition(animation.Transition{From: "cast", To: "idle", When: animation.Not(animCasting)})
+	a.AddTransition(animation.Transition{From: "idle", To: "walk", When: animation.Is(animMoving)})
+	a.AddTransition(animation.Transition{From: "walk", To: "idle", When: animation.Not(animMoving)})
+	return a, nil
+}
+
+// animate advances the animators of the player and the enemies.
+func (g *Game) animate() {
+	pc := g.PlayerCharacter
+	casting, _ := pc.Spellbook.Casting()
+	pc.Animator.Set(animDashing, pc.Dash.IsActive())
+	pc.Animator.Set(animCasting, casting != nil)
+	pc.Animator.Set(animDead, pc.IsDead())
+	g.stepAnimator(pc.Object)
+
+	for _, e := range g.Enemies {
+		e.Animator.Set(animCasting, e.State == EnemyAttack || e.State == EnemyCharge)
+		e.Animator.Set(animDead, e.IsDead())
+		g.stepAnimator(e.Object)
+	}
+}
+
+// stepAnimator runs one tick of the object's animator. Slowing effects slow the animation down with the movement.
+func (g *Game) stepAnimator(o *Object) {
+	if o.Animator == nil {
+		return
+	}
+
+	o.Animator.Face(o.Rotation)
+	o.Animator.Speed = o.Statuses.SpeedFactor()
+	o.Animator.Update(TickDuration())
+}
+
+// onHurtAnimation plays the hurt animation of anything animated which takes a direct hit.
+func (g *Game) onHurtAnimation(e DamageEvent) {
+	if e.Target.Animator != nil && !e.Damage.Periodic {
+		e.Target.Animator.Trigger(animHurt)
+	}
+}
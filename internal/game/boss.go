@@ -0,0 +1,85 @@
+package game
+
+import (
+	"dungeon/internal/gfx"
+	"fmt"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"go.uber.org/zap"
+	"image/color"
+	"slices"
+)
+
+const (
+	bossBarWidth  = 400
+	bossBarHeight = 12
+)
+
+// startBossFight releases a boss room's boss into the room and locks the player in with it. It does nothing once the
+// boss has been beaten.
+func (g *Game) startBossFight(room *Room) {
+	boss := room.Boss
+	if !room.IsBossRoom || boss == nil || boss.IsDead() {
+		return
+	}
+
+	if !slices.Contains(room.Enemies, boss) {
+		room.Enemies = append(room.Enemies, boss)
+	}
+
+	room.LockDoors()
+	zap.L().Info("Boss fight started", zap.String("boss", boss.Def.Name), zap.Uint64("frame", g.Frame))
+}
+
+// onBossDeath opens the boss room back up once its boss is dead.
+func (g *Game) onBossDeath(e DeathEvent) {
+	room := g.CurrentLevel.CurrentRoom()
+	if room.Boss == nil || e.Object != room.Boss.Object {
+		return
+	}
+
+	g.clearRoom(room)
+	zap.L().Info("Boss defeated", zap.String("boss", room.Boss.Def.Name), zap.Uint64("frame", g.Frame))
+	g.Events.Publish(BossDefeatedEvent{Room: room, Boss: room.Boss})
+}
+
+// activeBoss returns the boss the player is currently fighting, if any.
+func (g *Game) activeBoss() *Enemy {
+	room := g.CurrentLevel.CurrentRoom()
+	if !room.IsBossRoom || room.Boss == nil || room.Boss.IsDead() {
+		return nil
+	}
+	return room.Boss
+}
+
+// drawBossHealthBar draws the health of the boss being fought across the top of the screen.
+func (g *Game) drawBossHealthBar(screen *ebiten.Image) {
+	boss := g.activeBoss()
+	if boss == nil {
+		return
+	}
+
+	x := float32(gfx.ScreenWidth-bossBarWidth) / 2
+	y := float32(24)
+
+	vector.DrawFilledRect(screen, x-2, y-2, bossBarWidth+4, bossBarHeight+4, color.Black, false)
+	vector.DrawFilledRect(screen, x, y, bossBarWidth, bossBarHeight, color.RGBA{R: 0x40, A: 0xff}, false)
+	vector.DrawFilledRect(
+		screen,
+		x, y,
+		float32(bossBarWidth*boss.Health.Fraction()), bossBarHeight,
+		color.RGBA{R: 0xd0, G: 0x20, B: 0x20, A: 0xff},
+		false,
+	)
+
+	label := boss.Def.Name
+	if boss.Brain != nil {
+		if phase := boss.Brain.Blackboard.Float("phase", 0); phase > 0 {
+			label = fmt.Sprintf("%s - Phase %.0f", label, phase)
+		}
+	}
+	gfx.DrawText(screen, g.Font.Face(gfx.NormalText), label, gfx.ScreenWidth/2, int(y)+bossBarHeight+4, &gfx.TextOptions{
+		Align:   gfx.AlignCenter,
+		Outline: color.Black,
+	})
+}
@@ -0,0 +1,293 @@
+package game
+
+import (
+	"dungeon/internal/behavior"
+	"dungeon/internal/numerics"
+	"github.com/hajimehoshi/ebiten/v2"
+	"go.uber.org/zap"
+	"math/rand"
+)
+
+// perception is what an enemy knows about the player on the current tick.
+type perception struct {
+	toPlayer numerics.Vec2
+	distance float64
+	sees     bool
+}
+
+// brainAgent is the agent handed to the leaves of an enemy's behavior tree. Leaves read the game through it and
+// write the enemy's intended movement back into it, which is applied once the tree has finished ticking.
+type brainAgent struct {
+	game  *Game
+	room  *Room
+	enemy *Enemy
+	perception
+
+	direction numerics.Vec2
+	sprint    bool
+
+	// velocity, when set, replaces the enemy's velocity outright instead of steering it with its movement tuning.
+	velocity *numerics.Vec2
+}
+
+// begin puts the enemy into the state an action runs in. The time in the state starts over whenever a different action
+// takes over, even if it uses the same state.
+func (a *brainAgent) begin(action string, state EnemyState) {
+	e := a.enemy
+	if e.State != state || e.action != action {
+		e.setState(state)
+		e.action = action
+	}
+}
+
+// behaviors is the registry every enemy behavior tree is built from. It is filled in by init since some leaves create
+// enemies, which in turn build trees from it.
+var behaviors *behavior.Registry
+
+func init() {
+	behaviors = newBehaviorRegistry()
+}
+
+// newBehaviorRegistry registers the leaves enemy behavior trees can use.
+func newBehaviorRegistry() *behavior.Registry {
+	r := behavior.NewRegistry(ebiten.DefaultTPS)
+
+	r.RegisterCondition("can_see_player", brainCondition(func(a *brainAgent, _ behavior.Params) bool {
+		return a.sees
+	}))
+
+	r.RegisterCondition("player_alive", brainCondition(func(a *brainAgent, _ behavior.Params) bool {
+		return !a.game.PlayerCharacter.IsDead()
+	}))
+
+	r.RegisterCondition("player_within", brainCondition(func(a *brainAgent, params behavior.Params) bool {
+		return a.distance <= params.Float("distance", a.enemy.Def.SightRange)
+	}))
+
+	r.RegisterCondition("in_attack_range", brainCondition(func(a *brainAgent, _ behavior.Params) bool {
+		return a.distance <= a.enemy.Def.AttackRange
+	}))
+
+	r.RegisterCondition("attack_ready", brainCondition(func(a *brainAgent, _ behavior.Params) bool {
+		return a.enemy.attackCooldown == 0
+	}))
+
+	r.RegisterCondition("health_below", brainCondition(func(a *brainAgent, params behavior.Params) bool {
+		return a.enemy.Health.Fraction() < params.Float("fraction", a.enemy.Def.FleeBelow)
+	}))
+
+	r.RegisterAction("idle", brainAction(func(a *brainAgent, _ behavior.Params) behavior.Status {
+		a.begin("idle", EnemyIdle)
+		return behavior.Success
+	}))
+
+	r.RegisterAction("patrol", brainAction(actPatrol))
+	r.RegisterAction("chase_player", brainAction(actChase))
+	r.RegisterAction("attack_player", brainAction(actAttack))
+	r.RegisterAction("flee", brainAction(actFlee))
+	r.RegisterAction("phase", brainAction(actPhase))
+	r.RegisterAction("charge", brainAction(actCharge))
+	r.RegisterAction("slam", brainAction(actSlam))
+	r.RegisterAction("summon", brainAction(actSummon))
+	r.RegisterAction("emit", brainAction(actEmit))
+
+	r.RegisterCondition("emitting", brainCondition(func(a *brainAgent, _ behavior.Params) bool {
+		return len(a.enemy.Emitters) > 0
+	}))
+
+	return r
+}
+
+// brainCondition adapts a condition on a brainAgent to a behavior tree condition.
+func brainCondition(fn func(a *brainAgent, params behavior.Params) bool) behavior.ConditionFunc {
+	return func(ctx *behavior.Context, params behavior.Params) bool {
+		return fn(ctx.Agent.(*brainAgent), params)
+	}
+}
+
+// brainAction adapts an action on a brainAgent to a behavior tree action.
+func brainAction(fn func(a *brainAgent, params behavior.Params) behavior.Status) behavior.ActionFunc {
+	return func(ctx *behavior.Context, params behavior.Params) behavior.Status {
+		return fn(ctx.Agent.(*brainAgent), params)
+	}
+}
+
+// actPatrol walks to a random point near home. It succeeds on arrival and fails if there is no way there or the enemy
+// gets stuck.
+func actPatrol(a *brainAgent, _ behavior.Params) behavior.Status {
+	e := a.enemy
+	if e.State != EnemyPatrol || e.action != "patrol" || len(e.path) == 0 {
+		target := e.pickPatrolTarget(a.room).Add(e.Center.Sub(e.Position))
+		path, ok := a.room.NavGrid().FindPath(e.Center, target)
+		if !ok {
+			return behavior.Failure
+		}
+		e.path = path
+		e.setState(EnemyPatrol)
+		e.action = "patrol"
+	}
+
+	if e.stateTicks > patrolGiveUpTicks {
+		e.path = nil
+		return behavior.Failure
+	}
+
+	a.direction = e.followPath()
+	if a.direction.IsZero() {
+		e.path = nil
+		return behavior.Success
+	}
+	return behavior.Running
+}
+
+// actChase runs at the player until they are within attack range.
+func actChase(a *brainAgent, _ behavior.Params) behavior.Status {
+	a.begin("chase_player", EnemyChase)
+	if a.distance <= a.enemy.Def.AttackRange {
+		return behavior.Success
+	}
+
+	a.direction = a.enemy.chaseDirection(a.game, a.room, a.toPlayer)
+	a.sprint = true
+	return behavior.Running
+}
+
+// actAttack stands still through the wind up and then strikes, the strike only lands if the player is still in range.
+func actAttack(a *brainAgent, _ behavior.Params) behavior.Status {
+	e := a.enemy
+	a.begin("attack_player", EnemyAttack)
+	if e.stateTicks < SecondsToTicks(e.Def.AttackWindup) {
+		return behavior.Running
+	}
+
+	e.strike(a.game, a.distance)
+	// Leave the attack state so a following attack winds up again
+	e.setState(EnemyChase)
+	return behavior.Success
+}
+
+// actFlee runs directly away from the player until they are out of range.
+func actFlee(a *brainAgent, _ behavior.Params) behavior.Status {
+	a.begin("flee", EnemyFlee)
+	if a.distance > a.enemy.Def.LoseSightRange {
+		return behavior.Success
+	}
+
+	a.direction = a.toPlayer.MulScalar(-1)
+	a.sprint = true
+	return behavior.Running
+}
+
+// actPhase records which phase of a fight the enemy is in under "phase" on the blackboard. Moving into a new phase
+// makes the enemy briefly invulnerable for "invulnerability" seconds, so the change can be seen.
+func actPhase(a *brainAgent, params behavior.Params) behavior.Status {
+	e := a.enemy
+	phase := params.Float("phase", 1)
+
+	previous, ok := e.Brain.Blackboard.Get("phase")
+	e.Brain.Blackboard.Set("phase", phase)
+	if ok && previous != phase {
+		e.Health.Protect(SecondsToTicks(params.Float("invulnerability", 0)))
+		zap.L().Info("Phase change", zap.String("enemy", e.Def.Name), zap.Float64("phase", phase))
+	}
+
+	return behavior.Success
+}
+
+// actCharge winds up for "windup" seconds while tracking the player, then rushes in a straight line at "speed" pixels
+// per tick for "duration" seconds.
+func actCharge(a *brainAgent, params behavior.Params) behavior.Status {
+	e := a.enemy
+	a.begin("charge", EnemyCharge)
+
+	windup := SecondsToTicks(params.Float("windup", 0.5))
+	if e.stateTicks < windup {
+		if !a.toPlayer.IsZero() {
+			e.faceTowards(a.toPlayer)
+			e.Brain.Blackboard.Set("charge_direction", a.toPlayer.Normalized())
+		}
+		return behavior.Running
+	}
+
+	if e.stateTicks >= windup+SecondsToTicks(params.Float("duration", 0.5)) {
+		e.setState(EnemyChase)
+		return behavior.Success
+	}
+
+	value, _ := e.Brain.Blackboard.Get("charge_direction")
+	direction, _ := value.(numerics.Vec2)
+	velocity := direction.MulScalar(params.Float("speed", 6))
+	a.velocity = &velocity
+	return behavior.Running
+}
+
+// actSlam winds up for "windup" seconds and then hits the player if they are within "radius" pixels, for the enemy's
+// attack damage scaled by "multiplier".
+func actSlam(a *brainAgent, params behavior.Params) behavior.Status {
+	e := a.enemy
+	a.begin("slam", EnemyAttack)
+	if e.stateTicks < SecondsToTicks(params.Float("windup", 0.75)) {
+		return behavior.Running
+	}
+
+	player := a.game.PlayerCharacter
+	if a.distance <= params.Float("radius", 96) && !player.IsDead() {
+		damage := e.Def.Attack
+		damage.Amount *= params.Float("multiplier", 2)
+		damage.Source = e.Object
+		a.game.ApplyDamage(player.Object, damage)
+	}
+
+	e.setState(EnemyChase)
+	return behavior.Success
+}
+
+// actSummon spawns "count" of the "enemy" definition around the room, stopping once the room holds "max_alive"
+// enemies. It fails if nothing could be summoned.
+func actSummon(a *brainAgent, params behavior.Params) behavior.Status {
+	e := a.enemy
+	def, ok := a.game.CurrentLevel.Defs.Enemies[params.String("enemy", "")]
+	if !ok {
+		return behavior.Failure
+	}
+
+	summoned := 0
+	for i := 0; i < params.Int("count", 1) && len(a.room.Enemies) < params.Int("max_alive", 4); i++ {
+		minion, err := NewEnemy(def, numerics.ZeroVec2(), rand.New(rand.NewSource(e.rng.Int63())))
+		if err != nil {
+			return behavior.Failure
+		}
+
+		position, ok := findOpenPosition(e.rng, a.room, minion.Object)
+		if !ok {
+			continue
+		}
+		minion.UpdatePosition(position)
+		minion.Home = position
+
+		// Never drop a minion on top of the player
+		if minion.Overlaps(a.game.PlayerCharacter.AABB) {
+			continue
+		}
+
+		a.game.addEnemy(minion)
+		summoned++
+	}
+
+	if summoned == 0 {
+		return behavior.Failure
+	}
+	return behavior.Success
+}
+
+// actEmit starts firing the bullet pattern named by "pattern". The pattern carries on by itself, so the action succeeds
+// straight away, and the "emitting" condition can be used to wait for it to finish.
+func actEmit(a *brainAgent, params behavior.Params) behavior.Status {
+	def, ok := a.game.CurrentLevel.Defs.Patterns[params.String("pattern", "")]
+	if !ok {
+		return behavior.Failure
+	}
+
+	a.enemy.Emitters = append(a.enemy.Emitters, NewEmitter(def))
+	return behavior.Success
+}
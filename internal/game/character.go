@@ -2,140 +2,368 @@ package game
 
 import (
 	"dungeon/internal/animation"
+	"dungeon/internal/input"
 	"dungeon/internal/numerics"
+	"dungeon/internal/stats"
 
+	"fmt"
 	"github.com/hajimehoshi/ebiten/v2"
 	"go.uber.org/zap"
+	"maps"
 	"math"
+	"math/rand"
+	"strings"
 )
 
 // PlayerCharacter is a player character
 type PlayerCharacter struct {
+	// Def is the definition the character was created from
+	Def *CharacterDef
+
+	// Movement is the movement tuning for this character
+	Movement Movement
+
+	// Dash is the character's dash ability
+	Dash *Dash
+
+	// Attack is the damage dealt by the character's basic projectile, including anything equipped
+	Attack Damage
+
+	// Stats are the character's stats and the modifiers on them. Movement, Health and Attack follow them.
+	Stats *stats.Sheet
+
+	// Inventory holds the character's items
+	Inventory *Inventory
+
+	// Spellbook holds the character's spells and mana
+	Spellbook *Spellbook
+
+	// buffs is the number of ticks left on each timed modifier source
+	buffs map[string]int
+
+	// baseAttack and baseResistances are the character's own, before equipment is applied
+	baseAttack      Damage
+	baseResistances map[DamageType]float64
+
+	// equippedAttack is the attack once equipment is applied, its amount is replaced by the Damage stat
+	equippedAttack Damage
+
+	// fireCooldown is the number of ticks until the basic attack can fire again
+	fireCooldown int
+
+	// held is the set of actions held last tick, used to tell a fresh press from a hold
+	held input.Action
+
+	// pressed is the set of actions which went down this tick
+	pressed input.Action
+
 	*Object
 }
 
-func NewPlayerCharacter(screenWidth, screenHeight int) *PlayerCharacter {
+func NewPlayerCharacter(def *CharacterDef, screenWidth, screenHeight int) (*PlayerCharacter, error) {
 	zap.L().Info("Loading player character")
-	pc := NewObjectFromImages(map[Orientation]*animation.Image{
-		Front: animation.WizardFront,
-		Back:  animation.WizardFront,
-		Left:  animation.WizardSide,
-		Right: animation.WizardSide,
-	})
+	images, err := spriteSet(def.sprite)
+	if err != nil {
+		return nil, fmt.Errorf("character %s: %w", def.Name, err)
+	}
+
+	pc := NewObjectFromImages(images)
+	if pc.Animator, err = newCharacterAnimator(def.sprite); err != nil {
+		return nil, fmt.Errorf("character %s: %w", def.Name, err)
+	}
+	pc.Health = NewHealth(def.Health)
 	pc.UpdatePosition(numerics.NewVec2(float64(screenWidth/2), float64(screenHeight/2)))
-	return &PlayerCharacter{pc}
+
+	base := make(map[stats.Stat]float64, len(def.Stats)+3)
+	maps.Copy(base, def.Stats)
+	base[stats.MoveSpeed] = def.Movement.MaxSpeed
+	base[stats.MaxHealth] = def.Health.Max
+	base[stats.Damage] = def.Attack.Amount
+
+	return &PlayerCharacter{
+		Def:             def,
+		Movement:        def.Movement,
+		Dash:            NewDash(def.Dash),
+		Attack:          def.Attack,
+		Stats:           stats.NewSheet(base),
+		Inventory:       NewInventory(def.InventoryCapacity),
+		Spellbook:       NewSpellbook(def.spells, base[stats.MaxMana]),
+		buffs:           make(map[string]int),
+		baseAttack:      def.Attack,
+		equippedAttack:  def.Attack,
+		baseResistances: maps.Clone(pc.Health.Resistances),
+		Object:          pc,
+	}, nil
 }
 
-func (c *PlayerCharacter) Move(camera *Camera, objects []*Object, room *Room) {
-	// Handle the movement of the player with the keys
-	diff := c.handleKeyPress()
+func (c *PlayerCharacter) Move(state input.State, camera *Camera, objects []*Object, room *Room) {
+	c.pressed = state.Actions &^ c.held
+	c.held = state.Actions
 
-	// Check if the player is colliding with the boundary of the room.
-	diff = room.CheckCollisionAndUpdatePosition(c.Object, diff)
+	if c.fireCooldown > 0 {
+		c.fireCooldown--
+	}
+	c.stepBuffs()
+
+	direction := c.handleKeyPress(state)
 
-	// Depending on the collision axis, prevent movement in diff
-	if c.IsColliding {
-		// Keep track of the previous diff so we can undo it
-		oldDiff := diff
+	if c.pressed&input.Dash != 0 {
+		// Without any movement keys held, dash the way the character is facing
+		dashDirection := direction
+		if dashDirection.IsZero() {
+			dashDirection = numerics.NewVec2(math.Cos(c.Rotation), math.Sin(c.Rotation))
+		}
 
-		// First, apply the diff to the position
-		c.UpdatePosition(oldDiff)
+		if c.Dash.Start(dashDirection) {
+			c.Spellbook.Cancel()
+			c.Health.Protect(SecondsToTicks(c.Dash.Invulnerability))
+		}
+	}
 
-		// Does this move relieve the collision?
-		anyCollision := false
-		for _, a := range objects {
-			if a == c.Object {
-				continue
-			}
+	// Slowing effects cut the top speed, and the dash along with it
+	speedFactor := c.Statuses.SpeedFactor()
 
-			// Check for a bounding box collision
-			if c.IsExternallyColliding2D(a.AABB) {
-				anyCollision = true
-			}
+	wasDashing := c.Dash.IsActive()
+	if wasDashing {
+		c.Velocity = c.Dash.Direction.MulScalar(c.Dash.Speed * speedFactor)
+	} else {
+		// Accelerate towards wherever the keys are pointing
+		movement := c.Movement
+		movement.MaxSpeed *= speedFactor
+		c.Velocity = movement.Step(c.Velocity, direction, state.Pressed(input.Sprint))
+	}
+
+	diff := c.MoveAndCollide(room, objects)
+
+	c.Dash.Step(c.Object)
+	if wasDashing && !c.Dash.IsActive() {
+		// Come out of the dash at walking speed instead of carrying all of the dash speed
+		if !c.Velocity.IsZero() {
+			c.Velocity = c.Velocity.Normalized().MulScalar(c.Movement.MaxSpeed)
 		}
+	}
+
+	c.Animator.Set(animMoving, !diff.IsZero())
+
+	c.handleMouseMovement(state, camera)
+}
 
-		// If any of these are colliding, restrict the motion along the collision axis
-		if anyCollision {
-			if c.CollisionDirection.X {
-				diff = numerics.NewVec2(0, diff.Y())
-			}
+// HandleItems runs whichever inventory actions were pressed this tick, it must be called after Move.
+func (c *PlayerCharacter) HandleItems() {
+	if c.pressed&input.NextItem != 0 {
+		c.Inventory.SelectNext()
+	}
 
-			if c.CollisionDirection.Y {
-				diff = numerics.NewVec2(diff.X(), 0)
-			}
+	if c.pressed&input.UseItem != 0 {
+		if err := c.UseItem(c.Inventory.Selected); err != nil {
+			zap.L().Debug("Can't use item", zap.Error(err))
 		}
+	}
 
-		// Undo the position update.
-		c.UpdatePosition(oldDiff.MulScalar(-1))
+	if c.pressed&input.EquipItem != 0 {
+		if err := c.EquipItem(c.Inventory.Selected); err != nil {
+			zap.L().Debug("Can't equip item", zap.Error(err))
+		}
 	}
+}
 
-	// Only increment the count when the player is moving, otherwise reset to the start frame.
-	if diff.IsZero() {
-		c.Count = 0
-	} else {
-		c.Count++
+// UseItem uses up one of the item in the inventory stack at index.
+func (c *PlayerCharacter) UseItem(index int) error {
+	if index < 0 || index >= len(c.Inventory.Stacks) {
+		return ErrNoItem
+	}
+
+	item := c.Inventory.Stacks[index].Item
+	if !item.Usable() {
+		return fmt.Errorf("%s: %w", item.DisplayName, ErrNotUsable)
+	}
+
+	c.Health.Heal(item.Heal)
+	c.Inventory.Remove(index, 1)
+	return nil
+}
+
+// EquipItem equips the item in the inventory stack at index.
+func (c *PlayerCharacter) EquipItem(index int) error {
+	if err := c.Inventory.Equip(index); err != nil {
+		return err
+	}
+	c.applyEquipment()
+	return nil
+}
+
+// UnequipItem puts whatever is in the slot back into the inventory.
+func (c *PlayerCharacter) UnequipItem(slot EquipSlot) error {
+	if err := c.Inventory.Unequip(slot); err != nil {
+		return err
+	}
+	c.applyEquipment()
+	return nil
+}
+
+// applyEquipment recalculates the character's attack, resistances and stat modifiers from their own and what they
+// have equipped.
+func (c *PlayerCharacter) applyEquipment() {
+	attack := c.baseAttack
+	resistances := maps.Clone(c.baseResistances)
+
+	// Go through the slots in a fixed order so the result never depends on map iteration
+	for slot := WeaponSlot; slot <= TrinketSlot; slot++ {
+		source := equipmentSource(slot)
+
+		item := c.Inventory.Equipped[slot]
+		if item == nil {
+			c.Stats.Remove(source)
+			continue
+		}
+
+		if item.Attack != nil {
+			attack = *item.Attack
+		}
+
+		for t, r := range item.Resistances {
+			resistances[t] += r
+		}
+
+		c.Stats.Replace(source, item.Modifiers...)
+	}
+
+	c.equippedAttack = attack
+	c.Stats.SetBase(stats.Damage, attack.Amount)
+	c.Health.Resistances = resistances
+	c.applyStats()
+}
+
+// equipmentSource is the modifier source for whatever is equipped in the slot.
+func equipmentSource(slot EquipSlot) string {
+	return "equipment:" + strings.ToLower(slot.String())
+}
+
+// AddModifiers adds stat modifiers from the source, such as a buff or a level-up.
+func (c *PlayerCharacter) AddModifiers(source string, modifiers ...stats.Modifier) {
+	c.Stats.Add(source, modifiers...)
+	c.applyStats()
+}
+
+// RemoveModifiers removes every stat modifier from the source.
+func (c *PlayerCharacter) RemoveModifiers(source string) {
+	if c.Stats.Remove(source) > 0 {
+		c.applyStats()
 	}
+}
 
-	c.UpdatePosition(diff)
-	c.handleMouseMovement(camera)
+// AddBuff adds stat modifiers from the source for a number of ticks. Adding a buff which is already active replaces it
+// and restarts its timer.
+func (c *PlayerCharacter) AddBuff(source string, ticks int, modifiers ...stats.Modifier) {
+	c.Stats.Replace(source, modifiers...)
+	c.buffs[source] = ticks
+	c.applyStats()
 }
 
-func (c *PlayerCharacter) FireProjectile() {
-	// Get the normal direction
-	normal := MousePosition().Sub(c.Position).Normalized()
+// stepBuffs counts down timed buffs, removing any which have run out.
+func (c *PlayerCharacter) stepBuffs() {
+	for source, ticks := range c.buffs {
+		if ticks > 1 {
+			c.buffs[source] = ticks - 1
+			continue
+		}
+
+		delete(c.buffs, source)
+		c.RemoveModifiers(source)
+	}
+}
+
+// applyStats pushes the character's stats out to the movement, health and attack they drive. Raising max health heals
+// by the same amount, lowering it only caps the current health.
+func (c *PlayerCharacter) applyStats() {
+	c.Movement.MaxSpeed = c.Stats.Get(stats.MoveSpeed)
+
+	maxHealth := c.Stats.Get(stats.MaxHealth)
+	if gained := maxHealth - c.Health.Max; gained > 0 && !c.Health.IsDead() {
+		c.Health.Current += gained
+	}
+	c.Health.Max = maxHealth
+	c.Health.Current = min(c.Health.Current, maxHealth)
+
+	c.Attack = c.equippedAttack
+	c.Attack.Amount = c.Stats.Get(stats.Damage)
+}
+
+// IsInvulnerable reports whether the character is currently immune to damage.
+func (c *PlayerCharacter) IsInvulnerable() bool {
+	return c.Dash.IsInvulnerable() || c.Health.IsInvulnerable()
+}
+
+// Render draws the character along with any dash afterimages and the weapon in their hand. The weapon is drawn
+// behind the character while they face away from the camera.
+func (c *PlayerCharacter) Render(screen *ebiten.Image, cameraTransform *ebiten.GeoM) {
+	c.Dash.RenderTrail(screen, cameraTransform)
+
+	if c.Orientation == Back {
+		c.drawWeapon(screen, cameraTransform)
+		c.Object.Render(screen, cameraTransform)
+		return
+	}
+
+	c.Object.Render(screen, cameraTransform)
+	c.drawWeapon(screen, cameraTransform)
+}
+
+// FireProjectile fires the basic attack towards the cursor, if the fire rate allows it. rng rolls for critical hits.
+func (c *PlayerCharacter) FireProjectile(state input.State, camera *Camera, rng *rand.Rand) {
+	if c.fireCooldown > 0 {
+		return
+	}
+
+	// A fire rate of 0 leaves the attack unlimited
+	if rate := c.Stats.Get(stats.FireRate); rate > 0 {
+		c.fireCooldown = SecondsToTicks(1 / rate)
+	}
+
+	// Get the normal direction towards the cursor in world space
+	mx, my := camera.ScreenToWorld(state.CursorX, state.CursorY)
+	normal := numerics.NewVec2(mx, my).Sub(c.Position).Normalized()
 
 	// PLACEHOLDER: White box image 16x16
 	img := animation.NewImageFromImage(ebiten.NewImage(16, 16))
 
+	damage := c.Attack
+	if rng.Float64() < c.Stats.Get(stats.CritChance) {
+		damage.Amount *= max(c.Stats.Get(stats.CritMultiplier), 1)
+	}
+
 	// Create a new projectile
-	c.Object.FireProjectile(normal, img)
+	c.Object.FireProjectile(normal, img, damage)
 }
 
-func (c *PlayerCharacter) handleMouseMovement(camera *Camera) {
+func (c *PlayerCharacter) handleMouseMovement(state input.State, camera *Camera) {
 	// Handle the rotation of the player to face the direction of the mouse pointer
-	mouseX, mouseY := ebiten.CursorPosition()
-	mx, my := camera.ScreenToWorld(mouseX, mouseY)
+	mx, my := camera.ScreenToWorld(state.CursorX, state.CursorY)
 	normal := numerics.NewVec2(
 		mx-c.Position.X(),
 		my-c.Position.Y(),
 	)
 	c.Rotation = c.calculateXAxisAngleFromVec(normal.Normalized())
-
-	rotDeg := c.Rotation * 180 / math.Pi
-
-	if rotDeg >= -45 && rotDeg <= 45 {
-		c.Orientation = Right
-	} else if rotDeg >= 45 && rotDeg <= 135 {
-		c.Orientation = Front
-	} else if rotDeg >= 135 || rotDeg <= -135 {
-		c.Orientation = Left
-	} else if rotDeg >= -135 && rotDeg <= -45 {
-		c.Orientation = Back
-	}
+	c.Orientation = orientationFromAngle(c.Rotation)
 }
 
-func (c *PlayerCharacter) handleKeyPress() numerics.Vec2 {
+// handleKeyPress returns the direction the movement keys are pointing in, it is not normalized.
+func (c *PlayerCharacter) handleKeyPress(state input.State) numerics.Vec2 {
 	diff := numerics.ZeroVec2()
 
-	if ebiten.IsKeyPressed(ebiten.KeyW) || ebiten.IsKeyPressed(ebiten.KeyArrowUp) {
+	if state.Pressed(input.MoveUp) {
 		diff = diff.Add(numerics.NewVec2(0, -1))
-	} else if ebiten.IsKeyPressed(ebiten.KeyS) || ebiten.IsKeyPressed(ebiten.KeyArrowDown) {
+	} else if state.Pressed(input.MoveDown) {
 		diff = diff.Add(numerics.NewVec2(0, 1))
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyA) || ebiten.IsKeyPressed(ebiten.KeyArrowLeft) {
+	if state.Pressed(input.MoveLeft) {
 		diff = diff.Add(numerics.NewVec2(-1, 0))
-	} else if ebiten.IsKeyPressed(ebiten.KeyD) || ebiten.IsKeyPressed(ebiten.KeyArrowRight) {
+	} else if state.Pressed(input.MoveRight) {
 		diff = diff.Add(numerics.NewVec2(1, 0))
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyShiftLeft) {
-		c.Velocity = numerics.OneVec2().MulScalar(4)
-	} else {
-		c.Velocity = numerics.OneVec2().MulScalar(2)
-	}
-
-	return diff.Mul(c.Velocity)
+	return diff
 }
 
 // calculateXAxisAngleFromVec calculates the angle of the vector with respect to the x-axis. Assumes that the input
@@ -0,0 +1,70 @@
+package game
+
+import (
+	"dungeon/internal/numerics"
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+)
+
+// Checksummer receives a checksum of the simulation state at a fixed frame interval. Recording writes the checksums
+// out, playback compares them against the recording to detect divergence.
+type Checksummer interface {
+	Checksum(frame uint64, sum uint32) error
+}
+
+// Checksum hashes the parts of the simulation state which should be identical between two runs with the same seed and
+// input. Anything rendering-only, like the camera, is left out.
+func (g *Game) Checksum() uint32 {
+	h := fnv.New32a()
+	buf := make([]byte, 0, 64)
+
+	writeVec := func(v numerics.Vec2) {
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(v.X()))
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(v.Y()))
+	}
+
+	buf = binary.LittleEndian.AppendUint64(buf, g.Frame)
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(g.CurrentLevel.currentRoom))
+
+	pc := g.PlayerCharacter
+	writeVec(pc.Position)
+	writeVec(pc.Velocity)
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(pc.Rotation))
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(pc.Health.Current))
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(len(pc.Projectiles)))
+	for _, stack := range pc.Inventory.Stacks {
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(stack.Count))
+	}
+	_, _ = h.Write(buf)
+
+	for _, proj := range pc.Projectiles {
+		buf = buf[:0]
+		writeVec(proj.Position)
+		_, _ = h.Write(buf)
+	}
+
+	if spawner := g.CurrentLevel.CurrentRoom().Spawner; spawner != nil {
+		buf = buf[:0]
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(spawner.Wave))
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(spawner.delay))
+		_, _ = h.Write(buf)
+	}
+
+	for _, enemy := range g.Enemies {
+		buf = buf[:0]
+		writeVec(enemy.Position)
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(enemy.State))
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(enemy.Health.Current))
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(len(enemy.Projectiles)))
+		_, _ = h.Write(buf)
+	}
+
+	for _, proj := range g.strays {
+		buf = buf[:0]
+		writeVec(proj.Position)
+		_, _ = h.Write(buf)
+	}
+
+	return h.Sum32()
+}
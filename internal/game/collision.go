@@ -91,6 +91,51 @@ func (a *AABB) ResetCollisionState() {
 	a.CollisionDirection = CollisionDirection{}
 }
 
+// Overlaps reports whether the two boxes intersect, without touching either box's collision state.
+func (a *AABB) Overlaps(b *AABB) bool {
+	return a.Min.X() <= b.Max.X() && a.Max.X() >= b.Min.X() &&
+		a.Min.Y() <= b.Max.Y() && a.Max.Y() >= b.Min.Y()
+}
+
+// Contains reports whether the point lies inside the box.
+func (a *AABB) Contains(p numerics.Vec2) bool {
+	return p.X() >= a.Min.X() && p.X() <= a.Max.X() && p.Y() >= a.Min.Y() && p.Y() <= a.Max.Y()
+}
+
+// IntersectsSegment reports whether the line segment from p0 to p1 passes through the box.
+func (a *AABB) IntersectsSegment(p0, p1 numerics.Vec2) bool {
+	// Slab test, clip the segment against each axis in turn
+	tMin, tMax := 0.0, 1.0
+	d := p1.Sub(p0)
+
+	for axis := 0; axis < 2; axis++ {
+		origin, delta := p0.Vec2[axis], d.Vec2[axis]
+		lo, hi := a.Min.Vec2[axis], a.Max.Vec2[axis]
+
+		if delta == 0 {
+			// Parallel to this slab, so it has to start inside it
+			if origin < lo || origin > hi {
+				return false
+			}
+			continue
+		}
+
+		t0 := (lo - origin) / delta
+		t1 := (hi - origin) / delta
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+
+		tMin = max(tMin, t0)
+		tMax = min(tMax, t1)
+		if tMin > tMax {
+			return false
+		}
+	}
+
+	return true
+}
+
 // IsExternallyColliding2D checks whether a, which is outside b, is about to clip into b
 func (a *AABB) IsExternallyColliding2D(b *AABB) bool {
 	if a.Max.X() < b.Min.X() || a.Min.X() > b.Max.X() {
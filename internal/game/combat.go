@@ -0,0 +1,138 @@
+package game
+
+import (
+	"go.uber.org/zap"
+	"slices"
+)
+
+// ApplyDamage routes a hit through the target's health, publishing the damage and, if it was fatal, the death.
+// Objects without health ignore damage.
+func (g *Game) ApplyDamage(target *Object, damage Damage) {
+	if target.Health == nil || target.IsDead() {
+		return
+	}
+
+	dealt := target.Health.TakeDamage(damage)
+	if dealt == 0 {
+		return
+	}
+
+	g.Events.Publish(DamageEvent{Target: target, Damage: damage, Dealt: dealt})
+	if target.IsDead() {
+		g.Events.Publish(DeathEvent{Object: target, Killer: damage.Source})
+	}
+}
+
+// stepProjectiles moves every projectile the owner has fired, keeping those still in flight.
+func (g *Game) stepProjectiles(owner *Object) {
+	owner.Projectiles = g.moveProjectiles(owner.Projectiles)
+}
+
+// moveProjectiles moves every projectile, damaging the first thing with health each one touches and retiring any that
+// hit something, an obstacle included, or left the room. It returns the projectiles still in flight, reusing the slice.
+func (g *Game) moveProjectiles(all []*Projectile) []*Projectile {
+	room := g.CurrentLevel.CurrentRoom()
+	start, end := room.Bounds()
+	bounds := &AABB{Min: start, Max: end}
+
+	projectiles := all[:0]
+	for _, proj := range all {
+		proj.Step()
+
+		if !bounds.Contains(proj.Position) {
+			proj.Spent = true
+		}
+
+		// Pillars stop shots the same way they stop movement and sight
+		for _, obstacle := range room.Obstacles {
+			if !proj.Spent && !obstacle.Trigger && proj.Overlaps(obstacle.AABB) {
+				proj.Spent = true
+			}
+		}
+
+		for _, target := range g.Objects {
+			if proj.Spent {
+				break
+			}
+
+			if target == proj.Owner || target.Health == nil || target.IsDead() {
+				continue
+			}
+
+			if proj.Hostile && target != g.PlayerCharacter.Object {
+				continue
+			}
+
+			if proj.Overlaps(target.AABB) {
+				g.ApplyDamage(target, proj.Damage)
+				proj.Spent = true
+			}
+		}
+
+		if !proj.Spent {
+			projectiles = append(projectiles, proj)
+		}
+	}
+
+	// Clear the tail so the dropped projectiles can be collected
+	clear(all[len(projectiles):])
+	return projectiles
+}
+
+// applyContactDamage hurts the player for touching anything which deals contact damage.
+func (g *Game) applyContactDamage() {
+	player := g.PlayerCharacter.Object
+	for _, o := range g.Objects {
+		if o == player || o.ContactDamage == nil || o.IsDead() {
+			continue
+		}
+
+		if player.Overlaps(o.AABB) {
+			damage := *o.ContactDamage
+			damage.Source = o
+			g.ApplyDamage(player, damage)
+		}
+	}
+}
+
+// removeDead drops every dead object other than the player from the game. Projectiles a dead enemy still has in flight
+// carry on without it.
+func (g *Game) removeDead() {
+	for _, enemy := range g.Enemies {
+		if enemy.IsDead() {
+			g.strays = append(g.strays, enemy.Projectiles...)
+			enemy.Projectiles = nil
+		}
+	}
+
+	objects := g.Objects[:0]
+	for _, o := range g.Objects {
+		if o.IsDead() && o != g.PlayerCharacter.Object {
+			continue
+		}
+		objects = append(objects, o)
+	}
+
+	clear(g.Objects[len(objects):])
+	g.Objects = objects
+
+	g.Enemies = slices.DeleteFunc(g.Enemies, (*Enemy).IsDead)
+	room := g.CurrentLevel.CurrentRoom()
+	room.Enemies = slices.DeleteFunc(room.Enemies, (*Enemy).IsDead)
+}
+
+func (g *Game) onDeath(e DeathEvent) {
+	if e.Object == g.PlayerCharacter.Object {
+		zap.L().Info("Player died", zap.Uint64("frame", g.Frame))
+		return
+	}
+
+	zap.L().Debug("Object died", zap.Uint64("frame", g.Frame))
+
+	for _, enemy := range g.Enemies {
+		if enemy.Object == e.Object {
+			g.dropLoot(enemy.Def.Loot, enemy.Center)
+			return
+		}
+	}
+}
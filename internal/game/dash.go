@@ -0,0 +1,128 @@
+package game
+
+import (
+	"dungeon/internal/numerics"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	// dashTrailInterval is how many ticks pass between afterimages while dashing
+	dashTrailInterval = 2
+
+	// dashTrailLife is how many ticks an afterimage takes to fade out
+	dashTrailLife = 12
+)
+
+// DashDef is the data-driven tuning for a dash. Durations are in seconds.
+type DashDef struct {
+	// Speed is the speed of the dash in pixels per tick.
+	Speed float64 `json:"speed"`
+
+	// Duration is how long the dash lasts.
+	Duration float64 `json:"duration"`
+
+	// Cooldown is how long after starting a dash before another can start.
+	Cooldown float64 `json:"cooldown"`
+
+	// Invulnerability is how long after starting a dash the character cannot take damage.
+	Invulnerability float64 `json:"invulnerability"`
+}
+
+// Dash tracks an in-progress dash and the afterimages it leaves behind.
+type Dash struct {
+	DashDef
+
+	// Direction is the normalized direction of the current dash.
+	Direction numerics.Vec2
+
+	// ticks is the number of ticks left in the current dash.
+	ticks int
+
+	// cooldown is the number of ticks left until a dash can start again.
+	cooldown int
+
+	// invulnerable is the number of ticks of invulnerability left.
+	invulnerable int
+
+	trail []*dashGhost
+}
+
+// dashGhost is a faded copy of the object left at a point along the dash.
+type dashGhost struct {
+	object Object
+	life   int
+}
+
+func NewDash(def DashDef) *Dash {
+	return &Dash{DashDef: def}
+}
+
+// IsActive reports whether a dash is in progress.
+func (d *Dash) IsActive() bool {
+	return d.ticks > 0
+}
+
+// IsInvulnerable reports whether the dash is currently protecting from damage.
+func (d *Dash) IsInvulnerable() bool {
+	return d.invulnerable > 0
+}
+
+// Start begins a dash in the given direction if the cooldown has elapsed. It returns whether the dash started.
+func (d *Dash) Start(direction numerics.Vec2) bool {
+	if d.cooldown > 0 || d.IsActive() || direction.IsZero() {
+		return false
+	}
+
+	d.Direction = direction.Normalized()
+	d.ticks = max(1, SecondsToTicks(d.Duration))
+	d.cooldown = SecondsToTicks(d.Cooldown)
+	d.invulnerable = SecondsToTicks(d.Invulnerability)
+	return true
+}
+
+// Step advances the dash timers by one tick and, while dashing, leaves an afterimage of the object behind.
+func (d *Dash) Step(o *Object) {
+	if d.cooldown > 0 {
+		d.cooldown--
+	}
+
+	if d.invulnerable > 0 {
+		d.invulnerable--
+	}
+
+	// Fade the trail, dropping anything which has fully faded
+	trail := d.trail[:0]
+	for _, ghost := range d.trail {
+		ghost.life--
+		if ghost.life > 0 {
+			trail = append(trail, ghost)
+		}
+	}
+	d.trail = trail
+
+	if !d.IsActive() {
+		return
+	}
+
+	if d.ticks%dashTrailInterval == 0 {
+		ghost := &dashGhost{object: *o, life: dashTrailLife}
+		ghost.object.Op = &ebiten.DrawImageOptions{}
+		ghost.object.AABB = nil
+		if o.Animator != nil {
+			ghost.object.Animator = o.Animator.Snapshot()
+		}
+		d.trail = append(d.trail, ghost)
+	}
+
+	d.ticks--
+}
+
+// RenderTrail draws the afterimages, fading out as they age.
+func (d *Dash) RenderTrail(screen *ebiten.Image, cameraTransform *ebiten.GeoM) {
+	for _, ghost := range d.trail {
+		ghost.object.Op.ColorScale.Reset()
+		ghost.object.Op.ColorScale.Scale(0.6, 0.8, 1, 1)
+		ghost.object.Op.ColorScale.ScaleAlpha(0.5 * float32(ghost.life) / dashTrailLife)
+		ghost.object.drawSprite(screen, cameraTransform)
+	}
+}
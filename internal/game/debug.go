@@ -0,0 +1,63 @@
+package game
+
+import (
+	"dungeon/internal/behavior"
+	"fmt"
+	imgui "github.com/gabstv/cimgui-go"
+	"strings"
+)
+
+var (
+	// runningNodeColor highlights nodes which are running as of the last tick
+	runningNodeColor = imgui.NewVec4(0.3, 1, 0.3, 1)
+
+	// tickedNodeColor is for nodes which ran and finished on the last tick
+	tickedNodeColor = imgui.NewVec4(1, 1, 1, 1)
+
+	// staleNodeColor is for nodes which did not run on the last tick
+	staleNodeColor = imgui.NewVec4(0.5, 0.5, 0.5, 1)
+)
+
+// drawBehaviorDebug shows the enemies in the current room which are driven by a behavior tree and, for the selected
+// one, the state of every node in its tree.
+func (g *Game) drawBehaviorDebug() {
+	imgui.Begin("Behavior Trees")
+	defer imgui.End()
+
+	selected := false
+	for i, enemy := range g.Enemies {
+		if enemy.Brain == nil {
+			continue
+		}
+
+		label := fmt.Sprintf("%s %d (%s)", enemy.Def.Name, i, enemy.State)
+		if imgui.SelectableBoolV(label, enemy == g.debugEnemy, 0, imgui.NewVec2(0, 0)) {
+			g.debugEnemy = enemy
+		}
+		selected = selected || enemy == g.debugEnemy
+	}
+
+	// The selected enemy may have died or been left behind in another room
+	if !selected {
+		g.debugEnemy = nil
+		return
+	}
+
+	tree := g.debugEnemy.Brain
+	imgui.Separator()
+	imgui.Text(fmt.Sprintf("Tick %d", tree.CurrentTick()))
+
+	tree.Walk(func(n behavior.Node, depth int) {
+		status, tick := n.Last()
+
+		color := staleNodeColor
+		if tick == tree.CurrentTick() {
+			color = tickedNodeColor
+			if status == behavior.Running {
+				color = runningNodeColor
+			}
+		}
+
+		imgui.TextColored(color, fmt.Sprintf("%s%s: %s", strings.Repeat("  ", depth), n.Name(), status))
+	})
+}
@@ -0,0 +1,377 @@
+package game
+
+import (
+	"dungeon/internal/assets"
+	"dungeon/internal/behavior"
+	"dungeon/internal/loot"
+	"dungeon/internal/stats"
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap"
+	"io/fs"
+	"slices"
+)
+
+// CharacterDef is the data-driven tuning for a character.
+type CharacterDef struct {
+	// Name is the key the definition was loaded under.
+	Name string `json:"-"`
+
+	Movement Movement  `json:"movement"`
+	Dash     DashDef   `json:"dash"`
+	Health   HealthDef `json:"health"`
+
+	// Attack is the damage dealt by the character's basic projectile.
+	Attack Damage `json:"attack"`
+
+	// Sprite names the sprite the character is drawn with.
+	Sprite string `json:"sprite"`
+
+	// sprite is the definition Sprite was resolved to when the definitions were loaded.
+	sprite *SpriteDef
+
+	// InventoryCapacity is the number of item stacks the character can carry.
+	InventoryCapacity int `json:"inventory_capacity"`
+
+	// Stats are the base values of the stats which aren't covered by the tuning above, such as fire rate and crit
+	// chance. Move speed, max health and damage come from Movement, Health and Attack.
+	Stats map[stats.Stat]float64 `json:"stats"`
+
+	// Spells names the spells the character knows, the first of them start on the hotbar.
+	Spells []string `json:"spells"`
+
+	// spells are the definitions Spells was resolved to when the definitions were loaded.
+	spells []*SpellDef
+}
+
+// Definitions is every piece of data-driven tuning the game loads at startup.
+type Definitions struct {
+	Characters map[string]*CharacterDef
+	Enemies    map[string]*EnemyDef
+
+	// Bosses are only ever spawned in boss rooms.
+	Bosses map[string]*EnemyDef
+
+	// Encounters are the waves of enemies rooms can be given.
+	Encounters map[string]*EncounterDef
+
+	// Items are every item which can appear in the game.
+	Items map[string]*ItemDef
+
+	// Loot are the loot tables enemies and rooms drop items from.
+	Loot loot.Tables
+
+	// Spells are every spell a character can know.
+	Spells map[string]*SpellDef
+
+	// Statuses are the status effects hits can apply.
+	Statuses map[string]*StatusDef
+
+	// Patterns are the bullet patterns enemies can fire.
+	Patterns map[string]*PatternDef
+
+	// Behaviors are the behavior tree definitions enemies can name.
+	Behaviors map[string]*behavior.Spec
+
+	// Sprites are the character sprites characters and enemies can be drawn with.
+	Sprites map[string]*SpriteDef
+
+	// Assets loads the images and sprite sheets the definitions refer to.
+	Assets *assets.Manager
+}
+
+// LoadDefinitions reads all the definition files from the data file system, loading the assets they refer to with the
+// asset manager.
+func LoadDefinitions(fsys fs.FS, assets *assets.Manager) (*Definitions, error) {
+	defs := &Definitions{Assets: assets}
+
+	var err error
+	if defs.Statuses, err = loadDefs[StatusDef](fsys, "statuses.json"); err != nil {
+		return nil, err
+	}
+
+	if defs.Characters, err = loadDefs[CharacterDef](fsys, "characters.json"); err != nil {
+		return nil, err
+	}
+
+	if defs.Enemies, err = loadDefs[EnemyDef](fsys, "enemies.json"); err != nil {
+		return nil, err
+	}
+
+	if defs.Bosses, err = loadDefs[EnemyDef](fsys, "bosses.json"); err != nil {
+		return nil, err
+	}
+
+	if defs.Sprites, err = loadDefs[SpriteDef](fsys, "sprites.json"); err != nil {
+		return nil, err
+	}
+
+	if err := defs.resolveSprites(); err != nil {
+		return nil, err
+	}
+
+	if defs.Items, err = loadDefs[ItemDef](fsys, "items.json"); err != nil {
+		return nil, err
+	}
+
+	if defs.Spells, err = loadDefs[SpellDef](fsys, "spells.json"); err != nil {
+		return nil, err
+	}
+
+	for _, def := range defs.Characters {
+		for _, name := range def.Spells {
+			spell, ok := defs.Spells[name]
+			if !ok {
+				return nil, fmt.Errorf("character %s: unknown spell %q", def.Name, name)
+			}
+			def.spells = append(def.spells, spell)
+		}
+	}
+
+	if defs.Loot, err = loadLoot(fsys, defs); err != nil {
+		return nil, err
+	}
+
+	for _, def := range defs.allEnemies() {
+		if _, ok := defs.Loot[def.Loot]; def.Loot != "" && !ok {
+			return nil, fmt.Errorf("enemy %s: unknown loot table %q", def.Name, def.Loot)
+		}
+	}
+
+	if defs.Encounters, err = loadDefs[EncounterDef](fsys, "encounters.json"); err != nil {
+		return nil, err
+	}
+
+	for _, encounter := range defs.Encounters {
+		if err := encounter.validate(defs); err != nil {
+			return nil, err
+		}
+	}
+
+	if defs.Patterns, err = loadDefs[PatternDef](fsys, "patterns.json"); err != nil {
+		return nil, err
+	}
+
+	data, err := fs.ReadFile(fsys, "behaviors.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read behaviors.json: %w", err)
+	}
+
+	if defs.Behaviors, err = behavior.LoadSpecs(data); err != nil {
+		return nil, err
+	}
+
+	if err := defs.resolveBehaviors(); err != nil {
+		return nil, err
+	}
+
+	if err := defs.validateStatuses(); err != nil {
+		return nil, err
+	}
+
+	return defs, nil
+}
+
+// resolveSprites loads every sprite's sheet, checks it can be animated and points each character and enemy at the sprite
+// it names.
+func (d *Definitions) resolveSprites() error {
+	for _, name := range sortedKeys(d.Sprites) {
+		def := d.Sprites[name]
+		if def.Directions != 4 && def.Directions != 8 {
+			return fmt.Errorf("sprite %s: directions must be 4 or 8, not %d", name, def.Directions)
+		}
+
+		// A sheet comes back along with an error when its image is swapped for the placeholder
+		sheet, err := d.Assets.Sheet(def.Sheet)
+		if sheet == nil {
+			return fmt.Errorf("sprite %s: %w", name, err)
+		}
+		if err != nil {
+			zap.L().Error("Failed to load sprite sheet", zap.String("sprite", name), zap.Error(err))
+		}
+		def.sheet = sheet
+
+		if _, err := spriteStates(def); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range sortedKeys(d.Characters) {
+		def := d.Characters[name]
+		sprite, ok := d.Sprites[def.Sprite]
+		if !ok {
+			return fmt.Errorf("character %s: unknown sprite %q", name, def.Sprite)
+		}
+		def.sprite = sprite
+	}
+
+	for _, def := range d.allEnemies() {
+		sprite, ok := d.Sprites[def.Sprite]
+		if !ok {
+			return fmt.Errorf("enemy %s: unknown sprite %q", def.Name, def.Sprite)
+		}
+		def.sprite = sprite
+	}
+	return nil
+}
+
+// loadLoot reads the loot tables, checking every item they drop is defined.
+func loadLoot(fsys fs.FS, defs *Definitions) (loot.Tables, error) {
+	data, err := fs.ReadFile(fsys, "loot.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read loot.json: %w", err)
+	}
+
+	tables, err := loot.Load(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range tables.Items() {
+		if _, ok := defs.Items[item]; !ok {
+			return nil, fmt.Errorf("loot: unknown item %q", item)
+		}
+	}
+	return tables, nil
+}
+
+// validateStatuses checks that every status effect a hit can apply is defined.
+func (d *Definitions) validateStatuses() error {
+	hits := make(map[string]*Damage)
+	for _, name := range sortedKeys(d.Characters) {
+		hits["character "+name] = &d.Characters[name].Attack
+	}
+	for _, def := range d.allEnemies() {
+		hits["enemy "+def.Name] = &def.Attack
+		if def.ContactDamage != nil {
+			hits["enemy "+def.Name+" contact"] = def.ContactDamage
+		}
+	}
+	for _, name := range sortedKeys(d.Items) {
+		if attack := d.Items[name].Attack; attack != nil {
+			hits["item "+name] = attack
+		}
+	}
+	for _, name := range sortedKeys(d.Patterns) {
+		hits["pattern "+name] = &d.Patterns[name].Damage
+	}
+	for _, name := range sortedKeys(d.Spells) {
+		hits["spell "+name] = &d.Spells[name].Damage
+	}
+
+	for _, what := range sortedKeys(hits) {
+		for _, status := range hits[what].Statuses {
+			if _, ok := d.Statuses[status]; !ok {
+				return fmt.Errorf("%s: unknown status effect %q", what, status)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveBehaviors links each enemy to the behavior tree it names, checking the tree can be built so a bad definition
+// is reported at startup rather than when the enemy spawns.
+func (d *Definitions) resolveBehaviors() error {
+	for _, def := range d.allEnemies() {
+		if def.Behavior == "" {
+			continue
+		}
+
+		spec, ok := d.Behaviors[def.Behavior]
+		if !ok {
+			return fmt.Errorf("enemy %s: unknown behavior %q", def.Name, def.Behavior)
+		}
+
+		if err := behaviors.Validate(spec); err != nil {
+			return fmt.Errorf("enemy %s: %w", def.Name, err)
+		}
+
+		def.behavior = spec
+	}
+
+	for _, name := range sortedKeys(d.Behaviors) {
+		if err := d.validateEmits(d.Behaviors[name]); err != nil {
+			return fmt.Errorf("behavior %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// validateEmits checks that every pattern the "emit" actions in the tree fire is defined.
+func (d *Definitions) validateEmits(spec *behavior.Spec) error {
+	if spec.Type == "action" && spec.Action == "emit" {
+		pattern := spec.Params.String("pattern", "")
+		if _, ok := d.Patterns[pattern]; !ok {
+			return fmt.Errorf("emit: unknown pattern %q", pattern)
+		}
+	}
+
+	for _, child := range spec.Children {
+		if err := d.validateEmits(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BossNames returns the names of all boss definitions in a stable order.
+func (d *Definitions) BossNames() []string {
+	return sortedKeys(d.Bosses)
+}
+
+// EncounterNames returns the names of all encounter definitions in a stable order.
+func (d *Definitions) EncounterNames() []string {
+	return sortedKeys(d.Encounters)
+}
+
+// allEnemies returns every enemy and boss definition.
+func (d *Definitions) allEnemies() []*EnemyDef {
+	all := make([]*EnemyDef, 0, len(d.Enemies)+len(d.Bosses))
+	for _, def := range d.Enemies {
+		all = append(all, def)
+	}
+	for _, def := range d.Bosses {
+		all = append(all, def)
+	}
+	return all
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// named is implemented by every definition type so the loader can record the key it was loaded under.
+type named interface {
+	setName(name string)
+}
+
+func (d *CharacterDef) setName(name string) { d.Name = name }
+func (d *EnemyDef) setName(name string)     { d.Name = name }
+
+// loadDefs parses a JSON object of definitions keyed by name from the given file.
+func loadDefs[T any, PT interface {
+	*T
+	named
+}](fsys fs.FS, filename string) (map[string]*T, error) {
+	data, err := fs.ReadFile(fsys, filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+
+	defs := make(map[string]*T)
+	if err := json.Unmarshal(data, &defs); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+
+	for name, def := range defs {
+		PT(def).setName(name)
+	}
+
+	return defs, nil
+}
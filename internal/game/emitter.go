@@ -0,0 +1,211 @@
+package game
+
+import (
+	"dungeon/internal/animation"
+	"dungeon/internal/numerics"
+	"fmt"
+	"github.com/hajimehoshi/ebiten/v2"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// PatternKind is the shape a bullet pattern fires its projectiles in.
+type PatternKind int
+
+const (
+	// Radial fires Count projectiles spread evenly around a full circle.
+	Radial PatternKind = iota
+
+	// Spiral is a radial pattern whose arms turn by Rotation degrees every shot.
+	Spiral
+
+	// Fan fires Count projectiles across an arc of Spread degrees centered on the aim direction.
+	Fan
+
+	// Wave is a fan which sweeps from side to side by Amplitude degrees, Frequency times a second.
+	Wave
+)
+
+func (k PatternKind) String() string {
+	switch k {
+	case Radial:
+		return "Radial"
+	case Spiral:
+		return "Spiral"
+	case Fan:
+		return "Fan"
+	case Wave:
+		return "Wave"
+	default:
+		return "Unknown"
+	}
+}
+
+func (k PatternKind) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(k.String())), nil
+}
+
+func (k *PatternKind) UnmarshalText(text []byte) error {
+	for pk := Radial; pk <= Wave; pk++ {
+		if strings.EqualFold(pk.String(), string(text)) {
+			*k = pk
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown pattern kind %q", text)
+}
+
+// PatternDef is the data-driven description of a bullet pattern. Angles are in degrees, durations in seconds and
+// speeds in pixels per tick.
+type PatternDef struct {
+	// Name is the key the definition was loaded under.
+	Name string `json:"-"`
+
+	Kind PatternKind `json:"kind"`
+
+	// Count is the number of projectiles in each shot.
+	Count int `json:"count"`
+
+	// Speed is how fast the projectiles travel.
+	Speed float64 `json:"speed"`
+
+	// Shots is how many times the pattern fires, Interval apart, after waiting Delay.
+	Shots    int     `json:"shots"`
+	Interval float64 `json:"interval"`
+	Delay    float64 `json:"delay"`
+
+	// Aimed patterns are centered on the direction to the target when each shot fires, others start from Angle.
+	Aimed bool    `json:"aimed"`
+	Angle float64 `json:"angle"`
+
+	// Rotation turns the whole pattern a little further every shot.
+	Rotation float64 `json:"rotation"`
+
+	// Spread is the arc a fan or wave covers.
+	Spread float64 `json:"spread"`
+
+	// Amplitude and Frequency control how far and how quickly a wave sweeps.
+	Amplitude float64 `json:"amplitude"`
+	Frequency float64 `json:"frequency"`
+
+	Damage Damage `json:"damage"`
+
+	// Size is the width and height of each projectile in pixels, and Color its color.
+	Size  int        `json:"size"`
+	Color [3]float32 `json:"color"`
+
+	// image is shared by every projectile the pattern fires, it is created on first use.
+	image *animation.Image
+}
+
+func (d *PatternDef) setName(name string) { d.Name = name }
+
+// projectileImage returns the image the pattern's projectiles are drawn with.
+func (d *PatternDef) projectileImage() *animation.Image {
+	if d.image == nil {
+		size := max(d.Size, 2)
+		d.image = animation.NewImageFromImage(ebiten.NewImage(size, size))
+		d.image.Fill(color.RGBA{
+			R: uint8(d.Color[0] * 0xff),
+			G: uint8(d.Color[1] * 0xff),
+			B: uint8(d.Color[2] * 0xff),
+			A: 0xff,
+		})
+	}
+	return d.image
+}
+
+// angles returns the direction of every projectile in a shot, in radians. aim is the direction to the target and
+// elapsed is the number of ticks since the pattern started.
+func (d *PatternDef) angles(shot int, aim float64, elapsed int) []float64 {
+	base := d.Angle * math.Pi / 180
+	if d.Aimed {
+		base = aim
+	}
+	base += float64(shot) * d.Rotation * math.Pi / 180
+
+	count := max(d.Count, 1)
+	angles := make([]float64, count)
+
+	switch d.Kind {
+	case Radial, Spiral:
+		for i := range angles {
+			angles[i] = base + float64(i)*2*math.Pi/float64(count)
+		}
+	case Fan, Wave:
+		if d.Kind == Wave {
+			seconds := float64(elapsed) / ebiten.DefaultTPS
+			base += d.Amplitude * math.Pi / 180 * math.Sin(2*math.Pi*d.Frequency*seconds)
+		}
+
+		spread := d.Spread * math.Pi / 180
+		for i := range angles {
+			if count == 1 {
+				angles[i] = base
+				continue
+			}
+			angles[i] = base + spread*(float64(i)/float64(count-1)-0.5)
+		}
+	}
+
+	return angles
+}
+
+// Emitter fires a bullet pattern from an object over time.
+type Emitter struct {
+	Def *PatternDef
+
+	// shot is the number of shots fired so far.
+	shot int
+
+	// elapsed is the number of ticks since the emitter started.
+	elapsed int
+
+	// next is the tick the next shot fires on.
+	next int
+}
+
+func NewEmitter(def *PatternDef) *Emitter {
+	return &Emitter{Def: def, next: SecondsToTicks(def.Delay)}
+}
+
+// Done reports whether every shot has been fired.
+func (e *Emitter) Done() bool {
+	return e.shot >= max(e.Def.Shots, 1)
+}
+
+// Step advances the emitter by one tick, firing from the center of the owner if a shot is due. The projectiles are
+// hostile, so they only ever hit the player.
+func (e *Emitter) Step(owner *Object, target numerics.Vec2) {
+	if e.Done() {
+		return
+	}
+
+	if e.elapsed >= e.next {
+		toTarget := target.Sub(owner.Center)
+		aim := math.Atan2(toTarget.Y(), toTarget.X())
+
+		img := e.Def.projectileImage()
+		offset := numerics.NewVec2(float64(img.FrameWidth), float64(img.FrameHeight)).DivScalar(2)
+
+		for _, angle := range e.Def.angles(e.shot, aim, e.elapsed) {
+			direction := numerics.NewVec2(math.Cos(angle), math.Sin(angle))
+
+			p := NewProjectile(owner, direction, img, e.Def.Damage)
+			p.Hostile = true
+			p.Velocity = direction.MulScalar(e.Def.Speed)
+
+			// Fire from the middle of the owner rather than its corner
+			p.UpdatePosition(owner.Center.Sub(offset).Sub(p.Position))
+			p.Center = owner.Center
+
+			owner.Projectiles = append(owner.Projectiles, p)
+		}
+
+		e.shot++
+		e.next = e.elapsed + max(SecondsToTicks(e.Def.Interval), 1)
+	}
+
+	e.elapsed++
+}
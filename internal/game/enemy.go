@@ -0,0 +1,386 @@
+package game
+
+import (
+	"dungeon/internal/behavior"
+	"dungeon/internal/numerics"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+)
+
+// EnemyState is a state in the enemy AI state machine.
+type EnemyState int
+
+const (
+	EnemyIdle EnemyState = iota
+	EnemyPatrol
+	EnemyChase
+	EnemyAttack
+	EnemyFlee
+	EnemyCharge
+	EnemyDead
+)
+
+func (s EnemyState) String() string {
+	switch s {
+	case EnemyIdle:
+		return "Idle"
+	case EnemyPatrol:
+		return "Patrol"
+	case EnemyChase:
+		return "Chase"
+	case EnemyAttack:
+		return "Attack"
+	case EnemyFlee:
+		return "Flee"
+	case EnemyCharge:
+		return "Charge"
+	case EnemyDead:
+		return "Dead"
+	default:
+		return "Unknown"
+	}
+}
+
+// EnemyDef is the data-driven tuning for an enemy. Durations are in seconds and distances in pixels.
+type EnemyDef struct {
+	// Name is the key the definition was loaded under.
+	Name string `json:"-"`
+
+	// Sprite names the sprite the enemy is drawn with.
+	Sprite string `json:"sprite"`
+
+	// sprite is the definition Sprite was resolved to when the definitions were loaded.
+	sprite *SpriteDef
+
+	// Tint scales the red, green and blue channels of the sprite so enemies sharing a sprite can be told apart.
+	Tint [3]float32 `json:"tint"`
+
+	Movement      Movement  `json:"movement"`
+	Health        HealthDef `json:"health"`
+	ContactDamage *Damage   `json:"contact_damage"`
+
+	// Attack is the damage dealt when a melee attack lands.
+	Attack         Damage  `json:"attack"`
+	AttackRange    float64 `json:"attack_range"`
+	AttackWindup   float64 `json:"attack_windup"`
+	AttackCooldown float64 `json:"attack_cooldown"`
+
+	// SightRange is how close the player must be to be noticed, LoseSightRange is how far they must get to be lost.
+	SightRange     float64 `json:"sight_range"`
+	LoseSightRange float64 `json:"lose_sight_range"`
+
+	// PatrolRadius is how far from its spawn point the enemy wanders.
+	PatrolRadius float64 `json:"patrol_radius"`
+
+	// IdleTime is how long the enemy waits between patrols.
+	IdleTime float64 `json:"idle_time"`
+
+	// FleeBelow is the fraction of health below which the enemy runs from the player, 0 never flees.
+	FleeBelow float64 `json:"flee_below"`
+
+	// Behavior names the behavior tree which drives the enemy, the built in state machine is used when it is empty.
+	Behavior string `json:"behavior"`
+
+	// Loot names the loot table rolled when the enemy dies, nothing drops when it is empty.
+	Loot string `json:"loot"`
+
+	// behavior is the tree definition Behavior was resolved to when the definitions were loaded.
+	behavior *behavior.Spec
+}
+
+const (
+	// patrolGiveUpTicks is how long an enemy tries to reach a patrol point before deciding it is stuck
+	patrolGiveUpTicks = 300
+
+	// patrolArriveDistance is how close to a patrol point counts as arriving
+	patrolArriveDistance = 8
+
+	// lostSightTicks is how long the player can be out of sight before a chase is abandoned
+	lostSightTicks = 90
+)
+
+// Enemy is a hostile actor driven by a finite state machine, or by a behavior tree when its definition names one.
+type Enemy struct {
+	Def *EnemyDef
+
+	// State is the current state of the AI. Behavior tree leaves keep it up to date as well.
+	State EnemyState
+
+	// Brain is the enemy's behavior tree, nil when it uses the state machine.
+	Brain *behavior.Tree
+
+	// action is the behavior tree action which last set State.
+	action string
+
+	// Emitters are the bullet patterns the enemy is firing.
+	Emitters []*Emitter
+
+	// Home is where the enemy spawned, patrols stay near it.
+	Home numerics.Vec2
+
+	// stateTicks is the number of ticks spent in the current state.
+	stateTicks int
+
+	// path is the list of waypoints for the enemy's center while patrolling.
+	path []numerics.Vec2
+
+	// attackCooldown is the number of ticks until the enemy may attack again.
+	attackCooldown int
+
+	// unseenTicks is the number of ticks since the player was last in sight.
+	unseenTicks int
+
+	// rng drives the enemy's random choices, it is seeded from the level so behavior is reproducible.
+	rng *rand.Rand
+
+	*Object
+}
+
+func NewEnemy(def *EnemyDef, position numerics.Vec2, rng *rand.Rand) (*Enemy, error) {
+	images, err := spriteSet(def.sprite)
+	if err != nil {
+		return nil, fmt.Errorf("enemy %s: %w", def.Name, err)
+	}
+
+	obj := NewObjectFromImages(images)
+	if obj.Animator, err = newCharacterAnimator(def.sprite); err != nil {
+		return nil, fmt.Errorf("enemy %s: %w", def.Name, err)
+	}
+	obj.Health = NewHealth(def.Health)
+	obj.ContactDamage = def.ContactDamage
+	obj.UpdatePosition(position)
+
+	if def.Tint != [3]float32{} {
+		obj.Op.ColorScale.Scale(def.Tint[0], def.Tint[1], def.Tint[2], 1)
+	}
+
+	e := &Enemy{
+		Def:    def,
+		State:  EnemyIdle,
+		Home:   position,
+		rng:    rng,
+		Object: obj,
+	}
+
+	if def.behavior != nil {
+		if e.Brain, err = behaviors.Build(def.behavior); err != nil {
+			return nil, fmt.Errorf("enemy %s: %w", def.Name, err)
+		}
+	}
+
+	return e, nil
+}
+
+// Update runs one tick of the enemy's AI and moves the enemy.
+func (e *Enemy) Update(g *Game) {
+	if e.State == EnemyDead {
+		return
+	}
+
+	if e.IsDead() {
+		e.setState(EnemyDead)
+		e.Velocity = numerics.ZeroVec2()
+		return
+	}
+
+	room := g.CurrentLevel.CurrentRoom()
+	p := e.perceive(g, room)
+
+	if e.attackCooldown > 0 {
+		e.attackCooldown--
+	}
+
+	// A stunned enemy's AI and bullet patterns are frozen where they are until the stun wears off
+	direction := numerics.ZeroVec2()
+	var sprint bool
+	var velocity *numerics.Vec2
+	if !e.Statuses.Stunned() {
+		if e.Brain != nil {
+			agent := &brainAgent{game: g, room: room, enemy: e, perception: p, direction: numerics.ZeroVec2()}
+			e.Brain.Tick(agent)
+			direction, sprint, velocity = agent.direction, agent.sprint, agent.velocity
+		} else {
+			direction, sprint = e.runStateMachine(g, room, p)
+		}
+
+		e.stateTicks++
+
+		for _, emitter := range e.Emitters {
+			emitter.Step(e.Object, g.PlayerCharacter.Center)
+		}
+		e.Emitters = slices.DeleteFunc(e.Emitters, (*Emitter).Done)
+	}
+
+	speedFactor := e.Statuses.SpeedFactor()
+	if velocity != nil {
+		e.Velocity = velocity.MulScalar(speedFactor)
+	} else {
+		movement := e.Def.Movement
+		movement.MaxSpeed *= speedFactor
+		e.Velocity = movement.Step(e.Velocity, direction, sprint)
+	}
+	diff := e.MoveAndCollide(room, g.Objects)
+
+	e.Animator.Set(animMoving, !diff.IsZero())
+	if !diff.IsZero() {
+		e.faceTowards(diff)
+	}
+}
+
+// perceive works out where the player is relative to the enemy and whether it can see them.
+func (e *Enemy) perceive(g *Game, room *Room) perception {
+	player := g.PlayerCharacter
+	toPlayer := player.Center.Sub(e.Center)
+	distance := toPlayer.Length()
+	sees := !player.IsDead() && e.canSee(room, player.Object, distance)
+
+	if sees {
+		e.unseenTicks = 0
+	} else {
+		e.unseenTicks++
+	}
+
+	return perception{toPlayer: toPlayer, distance: distance, sees: sees}
+}
+
+// runStateMachine runs one tick of the built in state machine and returns the direction the enemy wants to move in.
+func (e *Enemy) runStateMachine(g *Game, room *Room, p perception) (numerics.Vec2, bool) {
+	player := g.PlayerCharacter
+	direction := numerics.ZeroVec2()
+	sprint := false
+
+	switch e.State {
+	case EnemyIdle:
+		if p.sees {
+			e.setState(EnemyChase)
+		} else if e.stateTicks >= SecondsToTicks(e.Def.IdleTime) {
+			target := e.pickPatrolTarget(room).Add(e.Center.Sub(e.Position))
+			if path, ok := room.NavGrid().FindPath(e.Center, target); ok {
+				e.path = path
+				e.setState(EnemyPatrol)
+			} else {
+				// Nowhere to go from here, wait and try another point
+				e.setState(EnemyIdle)
+			}
+		}
+	case EnemyPatrol:
+		direction = e.followPath()
+		if p.sees {
+			e.setState(EnemyChase)
+		} else if direction.IsZero() || e.stateTicks > patrolGiveUpTicks {
+			e.path = nil
+			e.setState(EnemyIdle)
+		}
+	case EnemyChase:
+		if e.shouldFlee() && p.sees {
+			e.setState(EnemyFlee)
+		} else if p.distance > e.Def.LoseSightRange || e.unseenTicks > lostSightTicks || player.IsDead() {
+			e.setState(EnemyIdle)
+		} else if p.distance <= e.Def.AttackRange && e.attackCooldown == 0 {
+			e.setState(EnemyAttack)
+		} else if p.distance > e.Def.AttackRange {
+			direction = e.chaseDirection(g, room, p.toPlayer)
+			sprint = true
+		}
+	case EnemyAttack:
+		// Stand still through the wind up, then the attack lands if the player is still in reach
+		if e.stateTicks >= SecondsToTicks(e.Def.AttackWindup) {
+			e.strike(g, p.distance)
+			e.setState(EnemyChase)
+		}
+	case EnemyFlee:
+		if p.distance > e.Def.LoseSightRange || player.IsDead() {
+			e.setState(EnemyIdle)
+		} else {
+			direction = p.toPlayer.MulScalar(-1)
+			sprint = true
+		}
+	}
+
+	return direction, sprint
+}
+
+// strike lands a melee attack on the player if they are within reach, and starts the attack cooldown either way.
+func (e *Enemy) strike(g *Game, distance float64) {
+	player := g.PlayerCharacter
+	if distance <= e.Def.AttackRange && !player.IsDead() {
+		damage := e.Def.Attack
+		damage.Source = e.Object
+		g.ApplyDamage(player.Object, damage)
+	}
+	e.attackCooldown = SecondsToTicks(e.Def.AttackCooldown)
+}
+
+// followPath returns the direction to the next waypoint on the path, dropping waypoints as they are reached. It is
+// zero once the path is finished.
+func (e *Enemy) followPath() numerics.Vec2 {
+	for len(e.path) > 0 && e.path[0].Sub(e.Center).Length() < patrolArriveDistance {
+		e.path = e.path[1:]
+	}
+
+	if len(e.path) == 0 {
+		return numerics.ZeroVec2()
+	}
+
+	return e.path[0].Sub(e.Center)
+}
+
+// chaseDirection heads straight for the player when nothing is in the way, otherwise it follows the shared flow field
+// around the obstacles.
+func (e *Enemy) chaseDirection(g *Game, room *Room, toPlayer numerics.Vec2) numerics.Vec2 {
+	grid := room.NavGrid()
+	if grid.HasClearLine(e.Center, g.PlayerCharacter.Center) {
+		return toPlayer
+	}
+
+	if direction, ok := g.playerFlowField().Direction(e.Center); ok {
+		return direction
+	}
+
+	return toPlayer
+}
+
+func (e *Enemy) setState(state EnemyState) {
+	e.State = state
+	e.stateTicks = 0
+}
+
+func (e *Enemy) shouldFlee() bool {
+	return e.Def.FleeBelow > 0 && e.Health.Fraction() < e.Def.FleeBelow
+}
+
+// canSee reports whether the target is within sight range and not hidden behind an obstacle.
+func (e *Enemy) canSee(room *Room, target *Object, distance float64) bool {
+	sightRange := e.Def.SightRange
+	if e.State == EnemyChase || e.State == EnemyAttack || e.State == EnemyFlee || e.State == EnemyCharge {
+		// Once engaged, the enemy keeps track of the player out to the longer range
+		sightRange = e.Def.LoseSightRange
+	}
+
+	if distance > sightRange {
+		return false
+	}
+
+	return room.HasLineOfSight(e.Center, target.Center)
+}
+
+// pickPatrolTarget chooses a random point within the patrol radius of home which is inside the room.
+func (e *Enemy) pickPatrolTarget(room *Room) numerics.Vec2 {
+	angle := e.rng.Float64() * 2 * math.Pi
+	distance := e.rng.Float64() * e.Def.PatrolRadius
+	target := e.Home.Add(numerics.NewVec2(math.Cos(angle), math.Sin(angle)).MulScalar(distance))
+
+	start, end := room.InteriorBounds(e.Object)
+	return numerics.NewVec2(
+		min(max(target.X(), start.X()), end.X()),
+		min(max(target.Y(), start.Y()), end.Y()),
+	)
+}
+
+// faceTowards turns the enemy to face the direction of travel, the same way the player faces the cursor.
+func (e *Enemy) faceTowards(direction numerics.Vec2) {
+	e.Rotation = math.Atan2(direction.Y(), direction.X())
+	e.Orientation = orientationFromAngle(e.Rotation)
+}
@@ -0,0 +1,95 @@
+package game
+
+import (
+	"reflect"
+)
+
+// Event is anything which can be published on the EventBus.
+type Event any
+
+// EventBus queues events raised during a tick and delivers them to subscribers in the order they were published.
+// Delivery is deferred to Dispatch so that handlers never run in the middle of another system's update.
+type EventBus struct {
+	handlers map[reflect.Type][]func(Event)
+	queue    []Event
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{handlers: make(map[reflect.Type][]func(Event))}
+}
+
+// Subscribe registers fn to be called with every published event of type E.
+func Subscribe[E Event](bus *EventBus, fn func(E)) {
+	t := reflect.TypeOf((*E)(nil)).Elem()
+	bus.handlers[t] = append(bus.handlers[t], func(e Event) {
+		fn(e.(E))
+	})
+}
+
+// Publish queues an event for the next Dispatch.
+func (b *EventBus) Publish(e Event) {
+	b.queue = append(b.queue, e)
+}
+
+// Dispatch delivers all queued events. Events published by handlers are delivered in the same call.
+func (b *EventBus) Dispatch() {
+	for i := 0; i < len(b.queue); i++ {
+		e := b.queue[i]
+		for _, handler := range b.handlers[reflect.TypeOf(e)] {
+			handler(e)
+		}
+	}
+	b.queue = b.queue[:0]
+}
+
+// DamageEvent is published whenever an object takes damage.
+type DamageEvent struct {
+	Target *Object
+	Damage Damage
+
+	// Dealt is the damage that was applied after resistances.
+	Dealt float64
+}
+
+// DeathEvent is published when an object's health reaches zero.
+type DeathEvent struct {
+	Object *Object
+
+	// Killer is the source of the killing blow, it may be nil.
+	Killer *Object
+}
+
+// RoomClearedEvent is published when the last enemy of a room's encounter, or its boss, is killed. Rewards for the
+// room are dropped in response to it.
+type RoomClearedEvent struct {
+	Room *Room
+}
+
+// BossDefeatedEvent is published when the boss of a boss room dies.
+type BossDefeatedEvent struct {
+	Room *Room
+	Boss *Enemy
+}
+
+// LevelCompleteEvent is published when the player leaves the level through the exit.
+type LevelCompleteEvent struct {
+	Level *Level
+}
+
+// TriggerEvent is published every tick something overlaps a trigger object.
+type TriggerEvent struct {
+	Trigger *Object
+	Other   *Object
+}
+
+// ItemPickedUpEvent is published when the player collects items from a pickup.
+type ItemPickedUpEvent struct {
+	Item  *ItemDef
+	Count int
+}
+
+// StatusAppliedEvent is published when a status effect is applied to an object, including when it stacks or refreshes.
+type StatusAppliedEvent struct {
+	Target *Object
+	Status *StatusDef
+}
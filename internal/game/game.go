@@ -2,12 +2,19 @@ package game
 
 import (
 	"dungeon/internal/gfx"
+	"dungeon/internal/input"
+	"dungeon/internal/nav"
 	"dungeon/internal/numerics"
+	"errors"
 	"fmt"
 	ebimgui "github.com/gabstv/ebiten-imgui/v3"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"go.uber.org/zap"
+	"image/color"
+	"io"
 	"math"
+	"slices"
 )
 
 type Game struct {
@@ -15,6 +22,157 @@ type Game struct {
 	Camera          *Camera
 	CurrentLevel    *Level
 	Objects         []*Object
+
+	// Enemies are the enemies in the current room, their objects are also in Objects.
+	Enemies []*Enemy
+
+	// flowField leads to the player through the current room, it is shared by every chasing enemy.
+	flowField      *nav.FlowField
+	flowFieldFrame uint64
+
+	// debugEnemy is the enemy whose behavior tree is shown in the debug overlay.
+	debugEnemy *Enemy
+
+	// Input is where the player's input comes from each frame, either live or from a replay.
+	Input input.Source
+
+	// Checksummer, when set, receives a checksum of the simulation state every ChecksumInterval frames.
+	Checksummer      Checksummer
+	ChecksumInterval uint64
+
+	// Frame is the number of simulation ticks that have run.
+	Frame uint64
+
+	// Events carries gameplay events between systems.
+	Events *EventBus
+
+	// LevelComplete is set once the player leaves through the level's exit.
+	LevelComplete bool
+
+	// flashes are the outlines of recent area spells
+	flashes []*areaFlash
+
+	// strays are projectiles still in flight whose owner has died
+	strays []*Projectile
+
+	// HotReload, when set, reloads assets and definitions from disk as they change.
+	HotReload *HotReload
+
+	// Font is the font the HUD and menus are drawn in.
+	Font *gfx.Font
+}
+
+func NewGame(playerCharacter *PlayerCharacter, level *Level, source input.Source) *Game {
+	g := &Game{
+		PlayerCharacter: playerCharacter,
+		Camera:          &Camera{ViewPort: numerics.NewVec2(gfx.ScreenWidth, gfx.ScreenHeight)},
+		CurrentLevel:    level,
+		Input:           source,
+		Events:          NewEventBus(),
+		Font:            loadFont(level.Defs.Assets),
+	}
+
+	Subscribe(g.Events, g.onDeath)
+	Subscribe(g.Events, g.onBossDeath)
+	Subscribe(g.Events, g.onRoomCleared)
+	Subscribe(g.Events, g.onTrigger)
+	Subscribe(g.Events, g.onHitStatuses)
+	Subscribe(g.Events, g.onHurtAnimation)
+
+	g.enterRoom(level.CurrentRoom())
+	return g
+}
+
+// enterRoom rebuilds the set of live objects from the contents of a room.
+func (g *Game) enterRoom(room *Room) {
+	g.startBossFight(room)
+	g.startEncounter(room)
+
+	g.Objects = make([]*Object, 0)
+	g.Objects = append(g.Objects, g.PlayerCharacter.Object)
+
+	for _, door := range room.Doors {
+		g.Objects = append(g.Objects, door.Object)
+	}
+
+	g.Objects = append(g.Objects, room.Obstacles...)
+
+	for _, pickup := range room.Pickups {
+		g.Objects = append(g.Objects, pickup.Object)
+	}
+
+	g.flowField = nil
+	g.Enemies = make([]*Enemy, 0, len(room.Enemies))
+	for _, enemy := range room.Enemies {
+		g.Enemies = append(g.Enemies, enemy)
+		g.Objects = append(g.Objects, enemy.Object)
+	}
+}
+
+// removeObject takes an object out of the live objects.
+func (g *Game) removeObject(o *Object) {
+	if i := slices.Index(g.Objects, o); i >= 0 {
+		g.Objects = slices.Delete(g.Objects, i, i+1)
+	}
+}
+
+// addEnemy puts a new enemy into the current room.
+func (g *Game) addEnemy(enemy *Enemy) {
+	room := g.CurrentLevel.CurrentRoom()
+	room.Enemies = append(room.Enemies, enemy)
+	g.Enemies = append(g.Enemies, enemy)
+	g.Objects = append(g.Objects, enemy.Object)
+}
+
+// useDoors moves the player through any unlocked door they are touching.
+func (g *Game) useDoors() {
+	player := g.PlayerCharacter
+	for _, door := range g.CurrentLevel.CurrentRoom().Doors {
+		if door.Locked || !player.Overlaps(door.AABB) {
+			continue
+		}
+
+		if door.To == nil {
+			if !g.LevelComplete {
+				g.LevelComplete = true
+				zap.L().Info("Level complete", zap.Int64("seed", g.CurrentLevel.Seed), zap.Uint64("frame", g.Frame))
+				g.Events.Publish(LevelCompleteEvent{Level: g.CurrentLevel})
+			}
+			return
+		}
+
+		g.travel(door.To)
+		return
+	}
+}
+
+// travel moves the player into another room, arriving in its center.
+func (g *Game) travel(room *Room) {
+	player := g.PlayerCharacter
+	g.CurrentLevel.Enter(room)
+
+	arrival := room.Center().Sub(player.Dimensions().DivScalar(2))
+	player.UpdatePosition(arrival.Sub(player.Position))
+	player.Velocity = numerics.ZeroVec2()
+	player.Projectiles = nil
+	player.Spellbook.Cancel()
+
+	g.flashes = nil
+	g.strays = nil
+	g.debugEnemy = nil
+	g.enterRoom(room)
+}
+
+// flowFieldRefreshTicks is how often the flow field towards the player is rebuilt while it is in use.
+const flowFieldRefreshTicks = 15
+
+// playerFlowField returns a flow field leading to the player, rebuilding it if it has gone stale.
+func (g *Game) playerFlowField() *nav.FlowField {
+	if g.flowField == nil || g.Frame-g.flowFieldFrame >= flowFieldRefreshTicks {
+		g.flowField = g.CurrentLevel.CurrentRoom().NavGrid().FlowField(g.PlayerCharacter.Center)
+		g.flowFieldFrame = g.Frame
+	}
+	return g.flowField
 }
 
 func (g *Game) Update() error {
@@ -22,6 +180,18 @@ func (g *Game) Update() error {
 	ebimgui.BeginFrame()
 	defer ebimgui.EndFrame()
 
+	if g.HotReload != nil && g.Frame%reloadInterval == 0 {
+		g.hotReload()
+	}
+
+	state, err := g.Input.Next()
+	if errors.Is(err, io.EOF) {
+		// The replay is over
+		return ebiten.Termination
+	} else if err != nil {
+		return err
+	}
+
 	for _, a := range g.Objects {
 		a.ResetCollisionState()
 	}
@@ -32,26 +202,84 @@ func (g *Game) Update() error {
 				continue
 			}
 
+			// Triggers are never solid, overlapping one is reported instead
+			if a.Trigger || b.Trigger {
+				if a.Trigger && !b.Trigger && a.Overlaps(b.AABB) {
+					g.Events.Publish(TriggerEvent{Trigger: a, Other: b})
+				}
+				continue
+			}
+
 			a.IsExternallyColliding2D(b.AABB)
 		}
 	}
 
-	g.PlayerCharacter.Move(g.Camera, g.Objects, g.CurrentLevel.CurrentRoom())
+	for _, o := range g.Objects {
+		if o.Health != nil {
+			o.Health.Step()
+		}
+	}
+	g.stepStatuses()
 
-	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
-		g.PlayerCharacter.FireProjectile()
+	// A stunned player can't act, though they still drift to a stop and face the cursor
+	if g.PlayerCharacter.Statuses.Stunned() {
+		state.Actions = 0
+		g.PlayerCharacter.Spellbook.Cancel()
 	}
 
-	for _, proj := range g.PlayerCharacter.Projectiles {
-		proj.Step()
+	// The dead don't get to move
+	if !g.PlayerCharacter.IsDead() {
+		g.PlayerCharacter.Move(state, g.Camera, g.Objects, g.CurrentLevel.CurrentRoom())
+
+		if state.Pressed(input.Fire) {
+			g.PlayerCharacter.FireProjectile(state, g.Camera, g.CurrentLevel.rng)
+		}
+
+		g.PlayerCharacter.HandleItems()
+		g.handleSpells(state)
+	}
+	g.stepFlashes()
+
+	for _, enemy := range g.Enemies {
+		enemy.Update(g)
+	}
+
+	g.stepProjectiles(g.PlayerCharacter.Object)
+	for _, enemy := range g.Enemies {
+		g.stepProjectiles(enemy.Object)
+	}
+	g.strays = g.moveProjectiles(g.strays)
+	g.applyContactDamage()
+	g.animate()
+
+	g.Events.Dispatch()
+	g.removeDead()
+
+	if err := g.stepEncounter(); err != nil {
+		return err
 	}
 
+	if !g.PlayerCharacter.IsDead() {
+		g.useDoors()
+	}
+
+	g.drawBehaviorDebug()
+	g.drawInventoryWindow()
+	g.drawAtlasDebug()
+
 	// Camera is always centered on the main PlayerCharacter
 	g.Camera.Position = numerics.NewVec2(
 		g.PlayerCharacter.Position.X()-gfx.ScreenWidth/2,
 		g.PlayerCharacter.Position.Y()-gfx.ScreenHeight/2,
 	)
 
+	g.Frame++
+	if g.Checksummer != nil && g.ChecksumInterval > 0 && g.Frame%g.ChecksumInterval == 0 {
+		if err := g.Checksummer.Checksum(g.Frame, g.Checksum()); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -62,9 +290,24 @@ func (g *Game) Draw(screen *ebiten.Image) {
 
 	// Render the level before the character otherwise it'll draw overtop of it.
 	g.CurrentLevel.Render(screen, &cameraTransform)
+	g.drawFlashes(screen, &cameraTransform)
+
+	for _, enemy := range g.Enemies {
+		enemy.Render(screen, &cameraTransform)
+	}
+
+	for _, enemy := range g.Enemies {
+		for _, proj := range enemy.Projectiles {
+			proj.Render(screen, &cameraTransform)
+		}
+	}
+	for _, proj := range g.strays {
+		proj.Render(screen, &cameraTransform)
+	}
 
 	// Draw the PlayerCharacter and translate them to whatever their current position is
 	g.PlayerCharacter.Render(screen, &cameraTransform)
+	g.drawStatusIcons(screen, &cameraTransform)
 
 	// Draw the projectiles
 	for _, proj := range g.PlayerCharacter.Projectiles {
@@ -109,6 +352,29 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		0, gfx.ScreenHeight-108,
 	)
 
+	ebitenutil.DebugPrintAt(
+		screen,
+		fmt.Sprintf("Health %s", g.PlayerCharacter.Health.String()),
+		0, gfx.ScreenHeight-120,
+	)
+
+	if spawner := g.CurrentLevel.CurrentRoom().Spawner; spawner != nil {
+		ebitenutil.DebugPrintAt(
+			screen,
+			fmt.Sprintf("Encounter %s, cleared %t", spawner.String(), g.CurrentLevel.CurrentRoom().Cleared),
+			0, gfx.ScreenHeight-132,
+		)
+	}
+
+	g.drawBossHealthBar(screen)
+	g.drawHotbar(screen)
+
+	if g.PlayerCharacter.IsDead() {
+		g.drawBanner(screen, "YOU DIED", color.RGBA{R: 0xd0, G: 0x20, B: 0x20, A: 0xff})
+	} else if g.LevelComplete {
+		g.drawBanner(screen, "LEVEL COMPLETE", color.RGBA{R: 0xf0, G: 0xc0, B: 0x40, A: 0xff})
+	}
+
 	ebimgui.Draw(screen)
 }
 
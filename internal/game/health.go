@@ -0,0 +1,161 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DamageType is the element of a source of damage, resistances are per type.
+type DamageType int
+
+const (
+	Physical DamageType = iota
+	Fire
+	Frost
+	Poison
+	Arcane
+)
+
+func (t DamageType) String() string {
+	switch t {
+	case Physical:
+		return "Physical"
+	case Fire:
+		return "Fire"
+	case Frost:
+		return "Frost"
+	case Poison:
+		return "Poison"
+	case Arcane:
+		return "Arcane"
+	default:
+		return "Unknown"
+	}
+}
+
+func (t DamageType) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(t.String())), nil
+}
+
+func (t *DamageType) UnmarshalText(text []byte) error {
+	for dt := Physical; dt <= Arcane; dt++ {
+		if strings.EqualFold(dt.String(), string(text)) {
+			*t = dt
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown damage type %q", text)
+}
+
+// Damage is a single hit.
+type Damage struct {
+	Amount float64    `json:"amount"`
+	Type   DamageType `json:"type"`
+
+	// Statuses names the status effects the hit applies to whatever it damages.
+	Statuses []string `json:"statuses"`
+
+	// Source is the object that dealt the damage, it may be nil.
+	Source *Object `json:"-"`
+
+	// Periodic damage comes from an effect over time, it ignores and does not start the invulnerability window.
+	Periodic bool `json:"-"`
+}
+
+// HealthDef is the data-driven tuning for Health. Durations are in seconds.
+type HealthDef struct {
+	Max float64 `json:"max"`
+
+	// Invulnerability is how long after a hit before more damage can be taken.
+	Invulnerability float64 `json:"invulnerability"`
+
+	// Resistances reduce incoming damage of a type by a fraction. 1 is immune, negative values are weaknesses.
+	Resistances map[DamageType]float64 `json:"resistances"`
+}
+
+// Health is the hit points of an object which can be damaged and killed.
+type Health struct {
+	Current float64
+	Max     float64
+
+	Resistances map[DamageType]float64
+
+	// InvulnerabilityTicks is how many ticks the object is immune for after taking a hit.
+	InvulnerabilityTicks int
+
+	// invulnerable is the number of ticks of immunity left.
+	invulnerable int
+}
+
+func NewHealth(def HealthDef) *Health {
+	resistances := make(map[DamageType]float64, len(def.Resistances))
+	for t, r := range def.Resistances {
+		resistances[t] = r
+	}
+
+	return &Health{
+		Current:              def.Max,
+		Max:                  def.Max,
+		Resistances:          resistances,
+		InvulnerabilityTicks: SecondsToTicks(def.Invulnerability),
+	}
+}
+
+func (h *Health) String() string {
+	return fmt.Sprintf("%.0f/%.0f", h.Current, h.Max)
+}
+
+func (h *Health) IsDead() bool {
+	return h.Current <= 0
+}
+
+func (h *Health) IsInvulnerable() bool {
+	return h.invulnerable > 0
+}
+
+// Fraction is the current health as a fraction of the max.
+func (h *Health) Fraction() float64 {
+	if h.Max <= 0 {
+		return 0
+	}
+	return max(0, h.Current/h.Max)
+}
+
+// Protect makes the object immune to damage for at least the given number of ticks.
+func (h *Health) Protect(ticks int) {
+	h.invulnerable = max(h.invulnerable, ticks)
+}
+
+// Step counts down the invulnerability window.
+func (h *Health) Step() {
+	if h.invulnerable > 0 {
+		h.invulnerable--
+	}
+}
+
+// TakeDamage applies a hit after resistances and starts the invulnerability window. It returns the damage dealt, which
+// is zero if the hit was ignored.
+func (h *Health) TakeDamage(d Damage) float64 {
+	if h.IsDead() || (h.IsInvulnerable() && !d.Periodic) {
+		return 0
+	}
+
+	dealt := max(0, d.Amount*(1-h.Resistances[d.Type]))
+	if dealt == 0 {
+		return 0
+	}
+
+	h.Current = max(0, h.Current-dealt)
+	if !d.Periodic {
+		h.invulnerable = h.InvulnerabilityTicks
+	}
+	return dealt
+}
+
+// Heal restores health up to the max. The dead cannot be healed.
+func (h *Health) Heal(amount float64) {
+	if h.IsDead() {
+		return
+	}
+	h.Current = min(h.Max, h.Current+amount)
+}
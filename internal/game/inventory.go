@@ -0,0 +1,156 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotEquippable is returned when equipping an item which has no equipment slot.
+	ErrNotEquippable = errors.New("item can't be equipped")
+
+	// ErrNotUsable is returned when using an item which has no use.
+	ErrNotUsable = errors.New("item can't be used")
+
+	// ErrInventoryFull is returned when an item has to go back into the inventory and there is no room for it.
+	ErrInventoryFull = errors.New("inventory is full")
+
+	// ErrNoItem is returned when a slot index does not hold an item.
+	ErrNoItem = errors.New("no item in slot")
+)
+
+// ItemStack is some number of a single item sharing an inventory slot.
+type ItemStack struct {
+	Item  *ItemDef
+	Count int
+}
+
+// Inventory holds a character's items, in at most Capacity stacks, and the items they have equipped.
+type Inventory struct {
+	Capacity int
+	Stacks   []ItemStack
+
+	// Equipped is the item in each equipment slot.
+	Equipped map[EquipSlot]*ItemDef
+
+	// Selected is the index of the stack the use and equip actions apply to.
+	Selected int
+}
+
+func NewInventory(capacity int) *Inventory {
+	return &Inventory{
+		Capacity: capacity,
+		Stacks:   make([]ItemStack, 0, capacity),
+		Equipped: make(map[EquipSlot]*ItemDef),
+	}
+}
+
+// Add puts up to count of the item into the inventory, topping up existing stacks before starting new ones. It returns
+// how many were added.
+func (inv *Inventory) Add(item *ItemDef, count int) int {
+	added := 0
+	for i := range inv.Stacks {
+		if added == count {
+			break
+		}
+
+		stack := &inv.Stacks[i]
+		if stack.Item != item {
+			continue
+		}
+
+		n := min(item.Stack()-stack.Count, count-added)
+		stack.Count += n
+		added += n
+	}
+
+	for added < count && len(inv.Stacks) < inv.Capacity {
+		n := min(item.Stack(), count-added)
+		inv.Stacks = append(inv.Stacks, ItemStack{Item: item, Count: n})
+		added += n
+	}
+
+	return added
+}
+
+// Remove takes up to count items out of the stack at index, dropping the stack once it is empty. It returns how many
+// were removed.
+func (inv *Inventory) Remove(index, count int) int {
+	if index < 0 || index >= len(inv.Stacks) {
+		return 0
+	}
+
+	stack := &inv.Stacks[index]
+	n := min(stack.Count, count)
+	stack.Count -= n
+
+	if stack.Count == 0 {
+		inv.Stacks = append(inv.Stacks[:index], inv.Stacks[index+1:]...)
+		if inv.Selected >= len(inv.Stacks) {
+			inv.Selected = max(len(inv.Stacks)-1, 0)
+		}
+	}
+
+	return n
+}
+
+// Count returns the total number of the item held, not counting an equipped one.
+func (inv *Inventory) Count(item *ItemDef) int {
+	total := 0
+	for _, stack := range inv.Stacks {
+		if stack.Item == item {
+			total += stack.Count
+		}
+	}
+	return total
+}
+
+// SelectNext moves the selection on to the next stack, wrapping around at the end.
+func (inv *Inventory) SelectNext() {
+	if len(inv.Stacks) == 0 {
+		inv.Selected = 0
+		return
+	}
+	inv.Selected = (inv.Selected + 1) % len(inv.Stacks)
+}
+
+// Equip moves one of the item at index into its equipment slot. Whatever was in the slot goes back into the
+// inventory, and if it doesn't fit nothing changes.
+func (inv *Inventory) Equip(index int) error {
+	if index < 0 || index >= len(inv.Stacks) {
+		return ErrNoItem
+	}
+
+	item := inv.Stacks[index].Item
+	if !item.Equippable() {
+		return fmt.Errorf("%s: %w", item.DisplayName, ErrNotEquippable)
+	}
+
+	previous := inv.Equipped[item.Slot]
+	inv.Remove(index, 1)
+	inv.Equipped[item.Slot] = item
+
+	if previous != nil && inv.Add(previous, 1) == 0 {
+		// Undo the swap, the old item has nowhere to go
+		inv.Equipped[item.Slot] = previous
+		inv.Add(item, 1)
+		return fmt.Errorf("unequip %s: %w", previous.DisplayName, ErrInventoryFull)
+	}
+
+	return nil
+}
+
+// Unequip moves the item in the slot back into the inventory.
+func (inv *Inventory) Unequip(slot EquipSlot) error {
+	item := inv.Equipped[slot]
+	if item == nil {
+		return nil
+	}
+
+	if inv.Add(item, 1) == 0 {
+		return fmt.Errorf("unequip %s: %w", item.DisplayName, ErrInventoryFull)
+	}
+
+	delete(inv.Equipped, slot)
+	return nil
+}
@@ -0,0 +1,252 @@
+package game
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+var (
+	testPotion = &ItemDef{Name: "potion", DisplayName: "Potion", MaxStack: 5}
+	testSword  = &ItemDef{Name: "sword", DisplayName: "Sword", Slot: WeaponSlot}
+	testAxe    = &ItemDef{Name: "axe", DisplayName: "Axe", Slot: WeaponSlot}
+	testKnives = &ItemDef{Name: "knives", DisplayName: "Throwing Knives", Slot: WeaponSlot, MaxStack: 3}
+)
+
+// newTestInventory makes an inventory holding the stacks.
+func newTestInventory(capacity int, stacks ...ItemStack) *Inventory {
+	inv := NewInventory(capacity)
+	inv.Stacks = append(inv.Stacks, stacks...)
+	return inv
+}
+
+func TestInventoryAdd(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		stacks   []ItemStack
+		item     *ItemDef
+		count    int
+		added    int
+		want     []ItemStack
+	}{
+		{
+			name:     "new stack",
+			capacity: 4,
+			item:     testPotion,
+			count:    3,
+			added:    3,
+			want:     []ItemStack{{testPotion, 3}},
+		},
+		{
+			name:     "split into full stacks",
+			capacity: 4,
+			item:     testPotion,
+			count:    12,
+			added:    12,
+			want:     []ItemStack{{testPotion, 5}, {testPotion, 5}, {testPotion, 2}},
+		},
+		{
+			name:     "tops up existing stacks first",
+			capacity: 4,
+			stacks:   []ItemStack{{testPotion, 4}, {testSword, 1}, {testPotion, 3}},
+			item:     testPotion,
+			count:    4,
+			added:    4,
+			want:     []ItemStack{{testPotion, 5}, {testSword, 1}, {testPotion, 5}, {testPotion, 1}},
+		},
+		{
+			name:     "items which don't stack",
+			capacity: 4,
+			item:     testSword,
+			count:    2,
+			added:    2,
+			want:     []ItemStack{{testSword, 1}, {testSword, 1}},
+		},
+		{
+			name:     "partial add at capacity",
+			capacity: 2,
+			stacks:   []ItemStack{{testSword, 1}},
+			item:     testPotion,
+			count:    7,
+			added:    5,
+			want:     []ItemStack{{testSword, 1}, {testPotion, 5}},
+		},
+		{
+			name:     "top up at capacity",
+			capacity: 1,
+			stacks:   []ItemStack{{testPotion, 3}},
+			item:     testPotion,
+			count:    4,
+			added:    2,
+			want:     []ItemStack{{testPotion, 5}},
+		},
+		{
+			name:     "full",
+			capacity: 1,
+			stacks:   []ItemStack{{testSword, 1}},
+			item:     testPotion,
+			count:    1,
+			added:    0,
+			want:     []ItemStack{{testSword, 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := newTestInventory(tt.capacity, tt.stacks...)
+			if added := inv.Add(tt.item, tt.count); added != tt.added {
+				t.Errorf("added %d, want %d", added, tt.added)
+			}
+			if !slices.Equal(inv.Stacks, tt.want) {
+				t.Errorf("stacks = %v, want %v", inv.Stacks, tt.want)
+			}
+		})
+	}
+}
+
+func TestInventoryRemove(t *testing.T) {
+	tests := []struct {
+		name     string
+		index    int
+		count    int
+		removed  int
+		want     []ItemStack
+		selected int
+	}{
+		{"some of a stack", 2, 2, 2, []ItemStack{{testSword, 1}, {testAxe, 1}, {testPotion, 2}}, 2},
+		{"whole stack", 2, 4, 4, []ItemStack{{testSword, 1}, {testAxe, 1}}, 1},
+		{"more than the stack holds", 2, 9, 4, []ItemStack{{testSword, 1}, {testAxe, 1}}, 1},
+		{"stack before the selection", 0, 1, 1, []ItemStack{{testAxe, 1}, {testPotion, 4}}, 1},
+		{"out of range", 3, 1, 0, []ItemStack{{testSword, 1}, {testAxe, 1}, {testPotion, 4}}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := newTestInventory(4, ItemStack{testSword, 1}, ItemStack{testAxe, 1}, ItemStack{testPotion, 4})
+			inv.Selected = 2
+
+			if removed := inv.Remove(tt.index, tt.count); removed != tt.removed {
+				t.Errorf("removed %d, want %d", removed, tt.removed)
+			}
+			if !slices.Equal(inv.Stacks, tt.want) {
+				t.Errorf("stacks = %v, want %v", inv.Stacks, tt.want)
+			}
+			if inv.Selected != tt.selected {
+				t.Errorf("selected = %d, want %d", inv.Selected, tt.selected)
+			}
+		})
+	}
+}
+
+func TestInventoryEquip(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		stacks   []ItemStack
+		equipped *ItemDef
+		index    int
+		err      error
+		want     []ItemStack
+		weapon   *ItemDef
+	}{
+		{
+			name:     "empty slot",
+			capacity: 2,
+			stacks:   []ItemStack{{testSword, 1}},
+			index:    0,
+			want:     []ItemStack{},
+			weapon:   testSword,
+		},
+		{
+			name:     "swap",
+			capacity: 2,
+			stacks:   []ItemStack{{testPotion, 2}, {testSword, 1}},
+			equipped: testAxe,
+			index:    1,
+			want:     []ItemStack{{testPotion, 2}, {testAxe, 1}},
+			weapon:   testSword,
+		},
+		{
+			name:     "swap into the freed slot when full",
+			capacity: 1,
+			stacks:   []ItemStack{{testSword, 1}},
+			equipped: testAxe,
+			index:    0,
+			want:     []ItemStack{{testAxe, 1}},
+			weapon:   testSword,
+		},
+		{
+			name:     "rolled back when full",
+			capacity: 1,
+			stacks:   []ItemStack{{testKnives, 2}},
+			equipped: testAxe,
+			index:    0,
+			err:      ErrInventoryFull,
+			want:     []ItemStack{{testKnives, 2}},
+			weapon:   testAxe,
+		},
+		{
+			name:     "not equippable",
+			capacity: 2,
+			stacks:   []ItemStack{{testPotion, 2}},
+			equipped: testAxe,
+			index:    0,
+			err:      ErrNotEquippable,
+			want:     []ItemStack{{testPotion, 2}},
+			weapon:   testAxe,
+		},
+		{
+			name:     "no item",
+			capacity: 2,
+			stacks:   []ItemStack{{testSword, 1}},
+			index:    1,
+			err:      ErrNoItem,
+			want:     []ItemStack{{testSword, 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := newTestInventory(tt.capacity, tt.stacks...)
+			if tt.equipped != nil {
+				inv.Equipped[WeaponSlot] = tt.equipped
+			}
+
+			if err := inv.Equip(tt.index); !errors.Is(err, tt.err) {
+				t.Errorf("Equip = %v, want %v", err, tt.err)
+			}
+			if !slices.Equal(inv.Stacks, tt.want) {
+				t.Errorf("stacks = %v, want %v", inv.Stacks, tt.want)
+			}
+			if weapon := inv.Equipped[WeaponSlot]; weapon != tt.weapon {
+				t.Errorf("weapon = %v, want %v", weapon, tt.weapon)
+			}
+		})
+	}
+}
+
+func TestInventoryUnequip(t *testing.T) {
+	inv := newTestInventory(1, ItemStack{testPotion, 1})
+	inv.Equipped[WeaponSlot] = testSword
+
+	if err := inv.Unequip(WeaponSlot); !errors.Is(err, ErrInventoryFull) {
+		t.Errorf("Unequip into a full inventory = %v, want ErrInventoryFull", err)
+	}
+	if inv.Equipped[WeaponSlot] != testSword {
+		t.Error("sword was unequipped with nowhere to go")
+	}
+
+	inv.Remove(0, 1)
+	if err := inv.Unequip(WeaponSlot); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := inv.Equipped[WeaponSlot]; ok || inv.Count(testSword) != 1 {
+		t.Errorf("equipped = %v, holding %d swords, want the sword back in the inventory", inv.Equipped, inv.Count(testSword))
+	}
+
+	// Nothing in the slot is nothing to do
+	if err := inv.Unequip(ArmorSlot); err != nil {
+		t.Errorf("Unequip of an empty slot = %v", err)
+	}
+}
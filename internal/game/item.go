@@ -0,0 +1,199 @@
+package game
+
+import (
+	"dungeon/internal/animation"
+	"dungeon/internal/numerics"
+	"dungeon/internal/stats"
+	"fmt"
+	"github.com/hajimehoshi/ebiten/v2"
+	"go.uber.org/zap"
+	"image/color"
+	"strings"
+)
+
+// EquipSlot is the place on the character an item is worn or held.
+type EquipSlot int
+
+const (
+	// NoSlot is for items which cannot be equipped.
+	NoSlot EquipSlot = iota
+	WeaponSlot
+	ArmorSlot
+	TrinketSlot
+)
+
+func (s EquipSlot) String() string {
+	switch s {
+	case NoSlot:
+		return "None"
+	case WeaponSlot:
+		return "Weapon"
+	case ArmorSlot:
+		return "Armor"
+	case TrinketSlot:
+		return "Trinket"
+	default:
+		return "Unknown"
+	}
+}
+
+func (s EquipSlot) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(s.String())), nil
+}
+
+func (s *EquipSlot) UnmarshalText(text []byte) error {
+	for es := NoSlot; es <= TrinketSlot; es++ {
+		if strings.EqualFold(es.String(), string(text)) {
+			*s = es
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown equip slot %q", text)
+}
+
+// ItemDef is the data-driven definition of an item.
+type ItemDef struct {
+	// Name is the key the definition was loaded under.
+	Name string `json:"-"`
+
+	DisplayName string `json:"display_name"`
+	Description string `json:"description"`
+
+	// MaxStack is how many of the item fit in one inventory slot, anything below 2 does not stack.
+	MaxStack int `json:"max_stack"`
+
+	// Slot is where the item goes when equipped.
+	Slot EquipSlot `json:"slot"`
+
+	// Heal is the health restored when the item is used, items which heal are used up.
+	Heal float64 `json:"heal"`
+
+	// Attack replaces the character's attack while the item is equipped.
+	Attack *Damage `json:"attack"`
+
+	// Resistances are added to the character's own while the item is equipped.
+	Resistances map[DamageType]float64 `json:"resistances"`
+
+	// Modifiers change the character's stats while the item is equipped.
+	Modifiers []stats.Modifier `json:"modifiers"`
+
+	// Color is the color of the item's pickup in the world.
+	Color [3]float32 `json:"color"`
+
+	// image is shared by every pickup of the item, it is created on first use.
+	image *animation.Image
+}
+
+func (d *ItemDef) setName(name string) { d.Name = name }
+
+// Stack is the number of the item that fit in one inventory slot.
+func (d *ItemDef) Stack() int {
+	return max(d.MaxStack, 1)
+}
+
+// Usable reports whether the item does something when used.
+func (d *ItemDef) Usable() bool {
+	return d.Heal > 0
+}
+
+// Equippable reports whether the item can be equipped.
+func (d *ItemDef) Equippable() bool {
+	return d.Slot != NoSlot
+}
+
+// pickupSize is the width and height of a pickup in the world
+const pickupSize = 12
+
+func (d *ItemDef) pickupImage() *animation.Image {
+	if d.image == nil {
+		d.image = animation.NewImageFromImage(ebiten.NewImage(pickupSize, pickupSize))
+		d.image.Fill(color.RGBA{
+			R: uint8(d.Color[0] * 0xff),
+			G: uint8(d.Color[1] * 0xff),
+			B: uint8(d.Color[2] * 0xff),
+			A: 0xff,
+		})
+	}
+	return d.image
+}
+
+// Pickup is a stack of items lying in the world. It is a trigger, so walking over it collects it rather than bumping
+// into it.
+type Pickup struct {
+	Item  *ItemDef
+	Count int
+
+	*Object
+}
+
+// NewPickup creates a pickup centered on the position.
+func NewPickup(item *ItemDef, count int, position numerics.Vec2) *Pickup {
+	obj := NewObjectFromImages(map[Orientation]*animation.Image{All: item.pickupImage()})
+	obj.Trigger = true
+	obj.UpdatePosition(position.Sub(obj.Dimensions().DivScalar(2)))
+	return &Pickup{Item: item, Count: count, Object: obj}
+}
+
+// dropItem places a pickup in the current room.
+func (g *Game) dropItem(item *ItemDef, count int, position numerics.Vec2) {
+	pickup := NewPickup(item, count, position)
+	room := g.CurrentLevel.CurrentRoom()
+	room.Pickups = append(room.Pickups, pickup)
+	g.Objects = append(g.Objects, pickup.Object)
+}
+
+// lootSpacing is the gap between the pickups of a single drop, so they don't land on top of each other
+const lootSpacing = pickupSize + 6
+
+// dropLoot rolls the named loot table and drops the result in a row centered on the position. Nothing drops for an
+// empty table name.
+func (g *Game) dropLoot(table string, position numerics.Vec2) {
+	if table == "" {
+		return
+	}
+
+	drops, err := g.CurrentLevel.RollLoot(table)
+	if err != nil {
+		zap.L().Error("Failed to roll loot", zap.String("table", table), zap.Error(err))
+		return
+	}
+
+	start := position.Sub(numerics.NewVec2(float64(len(drops)-1)*lootSpacing/2, 0))
+	for i, drop := range drops {
+		g.dropItem(g.CurrentLevel.Defs.Items[drop.Item], drop.Count, start.Add(numerics.NewVec2(float64(i)*lootSpacing, 0)))
+	}
+
+	if len(drops) > 0 {
+		zap.L().Debug("Loot dropped", zap.String("table", table), zap.Any("drops", drops), zap.Uint64("frame", g.Frame))
+	}
+}
+
+// onTrigger collects a pickup when the player walks over it. Whatever does not fit in the inventory stays on the
+// ground.
+func (g *Game) onTrigger(e TriggerEvent) {
+	player := g.PlayerCharacter
+	if e.Other != player.Object || player.IsDead() {
+		return
+	}
+
+	room := g.CurrentLevel.CurrentRoom()
+	for i, pickup := range room.Pickups {
+		if pickup.Object != e.Trigger {
+			continue
+		}
+
+		added := player.Inventory.Add(pickup.Item, pickup.Count)
+		if added == 0 {
+			return
+		}
+
+		pickup.Count -= added
+		g.Events.Publish(ItemPickedUpEvent{Item: pickup.Item, Count: added})
+
+		if pickup.Count == 0 {
+			room.Pickups = append(room.Pickups[:i], room.Pickups[i+1:]...)
+			g.removeObject(pickup.Object)
+		}
+		return
+	}
+}
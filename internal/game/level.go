@@ -3,41 +3,72 @@ package game
 import (
 	"dungeon/internal/animation"
 	"dungeon/internal/gfx"
+	"dungeon/internal/loot"
 	"dungeon/internal/numerics"
 	"github.com/hajimehoshi/ebiten/v2"
 	"image/color"
 	"math/rand"
 	"slices"
+	"sync"
 )
 
 const (
 	// TileSize is the size of a tile in pixels
 	TileSize = 16
+
+	// PillarSize is the width and height of a pillar obstacle in pixels
+	PillarSize = 3 * TileSize
+
+	// MinRoomSize and RoomSizeRange bound the width and height of generated rooms, before snapping to the tile size
+	MinRoomSize   = 500
+	RoomSizeRange = 1000
+
+	// spawnClearance is the radius around the center of a room which is kept free of pillars and enemies, since that
+	// is where the player arrives
+	spawnClearance = 120
 )
 
 func init() {
-	//GrassEmpty = NewTileFromImage(images.Tiles_png, 288, 139, TileSize)
+	//GrassEmpty, _ = tiles.Tile(288, 139, TileSize)
 	//GrassLevel = NewRoom(
 	//	numerics.NewVec2(gfx.ScreenWidth/2-500, gfx.ScreenHeight/2-250),
 	//	numerics.NewVec2(adjustToTileSize(1000), adjustToTileSize(500)))
 }
 
 type Level struct {
+	// Seed is the seed the level was generated from. The same seed always produces the same layout.
+	Seed int64
+
+	// Depth is how far into the dungeon the level is, starting from 1. Deeper levels drop rarer loot.
+	Depth int
+
+	// Defs are the definitions the level was generated from, for anything spawned after generation.
+	Defs *Definitions
+
 	rooms []*Room
 	doors []*Door
 
+	// rng is the level's random source, anything that needs to be reproducible from the seed must draw from it.
+	rng *rand.Rand
+
+	// lootRng rolls the level's drops. It is seeded from rng once the layout is done, so drops reproduce with the
+	// layout without rolling them changing it.
+	lootRng *rand.Rand
+
 	currentRoom int
 }
 
-func NewLevel() *Level {
+func NewLevel(seed int64, depth int, defs *Definitions) (*Level, error) {
+	rng := rand.New(rand.NewSource(seed))
+
 	// Generate a random number of rooms between 10-20
-	nRooms := 10 + rand.Intn(10)
+	nRooms := 10 + rng.Intn(10)
 	rooms := make([]*Room, nRooms)
 
 	for i := 0; i < nRooms; i++ {
-		// Random number between 1000-2000
-		roomWidth := adjustToTileSize(500 + rand.Intn(1000))
-		roomHeight := adjustToTileSize(500 + rand.Intn(1000))
+		// Random number between 500-1500
+		roomWidth := adjustToTileSize(MinRoomSize + rng.Intn(RoomSizeRange))
+		roomHeight := adjustToTileSize(MinRoomSize + rng.Intn(RoomSizeRange))
 
 		position := numerics.NewVec2(
 			gfx.ScreenWidth/2-float64(roomWidth)/2,
@@ -45,7 +76,7 @@ func NewLevel() *Level {
 		)
 		dimensions := numerics.NewVec2(roomWidth, roomHeight)
 
-		rooms[i] = NewRoom(position, dimensions)
+		rooms[i] = NewRoom(rng, position, dimensions)
 	}
 
 	// Every room has at least one door, and up to 2 more
@@ -59,76 +90,241 @@ func NewLevel() *Level {
 		// 3 - Bottom
 		nWalls := 1
 
-		if rand.Float64() < 0.1 {
+		if rng.Float64() < 0.1 {
 			nWalls = 2
 		}
 
 		usedWalls := make([]int, 0)
 		for w := 0; w < nWalls; w++ {
-			wall := rand.Intn(4)
+			wall := rng.Intn(4)
 			usedWalls = append(usedWalls, wall)
 
 			// Loop until the wall is not in usedWalls
 			for slices.Contains(usedWalls, wall) {
-				wall = rand.Intn(4)
+				wall = rng.Intn(4)
 			}
 
-			minX := rooms[i].Position.X()
-			minY := rooms[i].Position.Y()
+			rooms[i].Doors = append(rooms[i].Doors, newWallDoor(rooms[i], wall, rooms[i+1]))
+		}
+	}
 
-			endPos := rooms[i].Position.Add(rooms[i].Dimensions).SubScalar(float64(rooms[i].StrokeWidth / 2))
+	bossNames := defs.BossNames()
+	bossRoom := rooms[farthestRoom(rooms, 0)]
+	if len(bossNames) > 0 && bossRoom != rooms[0] {
+		bossRoom.IsBossRoom = true
 
-			maxX := endPos.X()
-			maxY := endPos.Y()
+		// The boss room is a dead end, the only other way out is the exit from the level
+		bossRoom.Doors = append(bossRoom.Doors, newWallDoor(bossRoom, rng.Intn(4), nil))
+	}
 
-			// Halfway between minX and maxX
-			halfX := minX + (maxX-minX)/2
-			halfY := minY + (maxY-minY)/2
+	for _, room := range rooms {
+		placePillars(rng, room)
 
-			// Put the door 50% of the way along the wall
-			var doorPosition numerics.Vec2
-			switch wall {
-			case 0: // Left
-				doorPosition = numerics.NewVec2(
-					minX,
-					halfY,
-				)
-				break
-			case 1: // Right
-				doorPosition = numerics.NewVec2(
-					maxX-10,
-					halfY,
-				)
-				break
-			case 2: // Top
-				doorPosition = numerics.NewVec2(
-					halfX,
-					minY,
-				)
-				break
-			case 3: // Bottom
-				doorPosition = numerics.NewVec2(
-					halfX,
-					maxY-10,
-				)
-				break
+		if room.IsBossRoom {
+			def := defs.Bosses[bossNames[rng.Intn(len(bossNames))]]
+			if err := placeBoss(rng, room, def); err != nil {
+				return nil, err
 			}
+			continue
+		}
 
-			width := (rooms[i].StrokeWidth / 2) * 1.5
-			height := (rooms[i].StrokeWidth / 2) * 1.5
-
-			doorImg := animation.NewImageFromImage(ebiten.NewImage(int(width), int(height)))
-			doorImg.Fill(color.White)
-			rooms[i].Doors = append(rooms[i].Doors, NewDoor(doorPosition, rooms[i+1], doorImg))
+		if names := defs.EncounterNames(); len(names) > 0 {
+			room.Spawner = NewSpawner(rng, room, defs.Encounters[names[rng.Intn(len(names))]])
 		}
 	}
 
 	return &Level{
+		Seed:        seed,
+		Depth:       depth,
+		Defs:        defs,
 		rooms:       rooms,
+		rng:         rng,
+		lootRng:     rand.New(rand.NewSource(rng.Int63())),
 		currentRoom: 0,
+	}, nil
+}
+
+// farthestRoom returns the index of the room which takes the most doors to reach from the start room.
+func farthestRoom(rooms []*Room, start int) int {
+	distance := map[*Room]int{rooms[start]: 0}
+	queue := []*Room{rooms[start]}
+	farthest := rooms[start]
+
+	for len(queue) > 0 {
+		room := queue[0]
+		queue = queue[1:]
+
+		if distance[room] > distance[farthest] {
+			farthest = room
+		}
+
+		for _, door := range room.Doors {
+			if door.To == nil {
+				continue
+			}
+
+			if _, seen := distance[door.To]; !seen {
+				distance[door.To] = distance[room] + 1
+				queue = append(queue, door.To)
+			}
+		}
+	}
+
+	return slices.Index(rooms, farthest)
+}
+
+// placeBoss creates the boss of a boss room across the room from where the player arrives. It stays out of the room's
+// enemies until the fight starts.
+func placeBoss(rng *rand.Rand, room *Room, def *EnemyDef) error {
+	boss, err := NewEnemy(def, numerics.ZeroVec2(), rand.New(rand.NewSource(rng.Int63())))
+	if err != nil {
+		return err
+	}
+
+	position := room.Center().Sub(boss.Dimensions().DivScalar(2)).Sub(numerics.NewVec2(0, room.Dimensions.Y()/4))
+	boss.UpdatePosition(position)
+	boss.Home = position
+	room.Boss = boss
+
+	// Clear away any pillar the boss would be stuck in
+	room.Obstacles = slices.DeleteFunc(room.Obstacles, func(o *Object) bool {
+		return o.Overlaps(boss.AABB)
+	})
+	return nil
+}
+
+// newWallDoor creates a door halfway along one of the room's walls leading to another room.
+//
+// 0 - Left
+// 1 - Right
+// 2 - Top
+// 3 - Bottom
+func newWallDoor(room *Room, wall int, to *Room) *Door {
+	minX := room.Position.X()
+	minY := room.Position.Y()
+
+	endPos := room.Position.Add(room.Dimensions).SubScalar(float64(room.StrokeWidth / 2))
+
+	maxX := endPos.X()
+	maxY := endPos.Y()
+
+	// Halfway between minX and maxX
+	halfX := minX + (maxX-minX)/2
+	halfY := minY + (maxY-minY)/2
+
+	// Put the door 50% of the way along the wall
+	var doorPosition numerics.Vec2
+	switch wall {
+	case 0: // Left
+		doorPosition = numerics.NewVec2(
+			minX,
+			halfY,
+		)
+		break
+	case 1: // Right
+		doorPosition = numerics.NewVec2(
+			maxX-10,
+			halfY,
+		)
+		break
+	case 2: // Top
+		doorPosition = numerics.NewVec2(
+			halfX,
+			minY,
+		)
+		break
+	case 3: // Bottom
+		doorPosition = numerics.NewVec2(
+			halfX,
+			maxY-10,
+		)
+		break
+	}
+
+	width := (room.StrokeWidth / 2) * 1.5
+	height := (room.StrokeWidth / 2) * 1.5
+
+	doorImg := animation.NewImageFromImage(ebiten.NewImage(int(width), int(height)))
+	doorImg.Fill(color.White)
+	return NewDoor(doorPosition, to, doorImg)
+}
+
+// NewPillar creates a solid pillar obstacle with its top-left corner at the position.
+func NewPillar(position numerics.Vec2) *Object {
+	pillar := NewObjectFromImages(map[Orientation]*animation.Image{All: pillarImage()})
+	pillar.UpdatePosition(position)
+	return pillar
+}
+
+// pillarImage is the placeholder image shared by every pillar.
+var pillarImage = sync.OnceValue(func() *animation.Image {
+	img := animation.NewImageFromImage(ebiten.NewImage(PillarSize, PillarSize))
+	img.Fill(color.RGBA{R: 0x60, G: 0x60, B: 0x60, A: 0xff})
+	return img
+})
+
+// placePillars scatters up to four pillars on the tile grid inside the room, away from its center.
+func placePillars(rng *rand.Rand, room *Room) {
+	start, end := room.Bounds()
+	start = start.AddScalar(float64(room.StrokeWidth))
+	end = end.SubScalar(float64(room.StrokeWidth) + PillarSize)
+	center := room.Center()
+
+	nPillars := rng.Intn(5)
+	for i := 0; i < nPillars; i++ {
+		position := numerics.NewVec2(
+			start.X()+adjustToTileSize(rng.Intn(int(end.X()-start.X()))),
+			start.Y()+adjustToTileSize(rng.Intn(int(end.Y()-start.Y()))),
+		)
+
+		pillarCenter := position.AddScalar(PillarSize / 2)
+		if pillarCenter.Sub(center).Length() < spawnClearance+PillarSize {
+			continue
+		}
+
+		room.Obstacles = append(room.Obstacles, NewPillar(position))
 	}
 }
 
+// findOpenPosition picks a random position inside the room where the object would not overlap an obstacle or be
+// within the spawn clearance of the room center.
+func findOpenPosition(rng *rand.Rand, room *Room, object *Object) (numerics.Vec2, bool) {
+	start, end := room.InteriorBounds(object)
+	center := room.Center()
+	size := object.Dimensions()
+
+	for attempt := 0; attempt < 20; attempt++ {
+		position := numerics.NewVec2(
+			start.X()+rng.Float64()*(end.X()-start.X()),
+			start.Y()+rng.Float64()*(end.Y()-start.Y()),
+		)
+
+		if position.Sub(center).Length() < spawnClearance {
+			continue
+		}
+
+		box := &AABB{Min: position, Max: position.Add(size)}
+		blocked := false
+		for _, obstacle := range room.Obstacles {
+			if box.Overlaps(obstacle.AABB) {
+				blocked = true
+				break
+			}
+		}
+
+		if !blocked {
+			return position, true
+		}
+	}
+
+	return numerics.Vec2{}, false
+}
+
+// RollLoot rolls the named loot table for the level's depth.
+func (l *Level) RollLoot(table string) ([]loot.Drop, error) {
+	return l.Defs.Loot.Roll(l.lootRng, table, l.Depth)
+}
+
 func (l *Level) Doors() []*Door {
 	doors := make([]*Door, 0)
 	for _, room := range l.rooms {
@@ -146,6 +342,13 @@ func (l *Level) CurrentRoom() *Room {
 	return l.rooms[l.currentRoom]
 }
 
+// Enter makes the room the current room.
+func (l *Level) Enter(room *Room) {
+	if i := slices.Index(l.rooms, room); i >= 0 {
+		l.currentRoom = i
+	}
+}
+
 func (l *Level) Render(screen *ebiten.Image, cameraTransform *ebiten.GeoM) {
 	l.CurrentRoom().Render(screen, cameraTransform)
 }
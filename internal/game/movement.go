@@ -0,0 +1,48 @@
+package game
+
+import (
+	"dungeon/internal/numerics"
+)
+
+// Movement describes how an object speeds up, slows down and how fast it may go. All speeds are in pixels per tick.
+type Movement struct {
+	// Acceleration is how much speed is gained each tick while there is input.
+	Acceleration float64 `json:"acceleration"`
+
+	// Friction is how much speed is lost each tick while there is no input, or while above the max speed.
+	Friction float64 `json:"friction"`
+
+	// MaxSpeed is the top speed when walking.
+	MaxSpeed float64 `json:"max_speed"`
+
+	// SprintMultiplier scales MaxSpeed while sprinting.
+	SprintMultiplier float64 `json:"sprint_multiplier"`
+}
+
+// Step returns the new velocity after one tick of accelerating in the input direction. The direction does not need to
+// be normalized, only its heading is used so diagonal movement is no faster than movement along one axis.
+func (m Movement) Step(velocity, direction numerics.Vec2, sprint bool) numerics.Vec2 {
+	maxSpeed := m.MaxSpeed
+	if sprint && m.SprintMultiplier > 0 {
+		maxSpeed *= m.SprintMultiplier
+	}
+
+	if !direction.IsZero() {
+		velocity = velocity.Add(direction.Normalized().MulScalar(m.Acceleration))
+
+		speed := velocity.Length()
+		if speed > maxSpeed {
+			// Bleed off extra speed (e.g. after letting go of sprint) with friction rather than snapping to the limit
+			velocity = velocity.MulScalar(max(maxSpeed, speed-m.Friction) / speed)
+		}
+
+		return velocity
+	}
+
+	speed := velocity.Length()
+	if speed <= m.Friction {
+		return numerics.ZeroVec2()
+	}
+
+	return velocity.MulScalar((speed - m.Friction) / speed)
+}
@@ -4,7 +4,6 @@ import (
 	"dungeon/internal/animation"
 	"dungeon/internal/numerics"
 	"github.com/hajimehoshi/ebiten/v2"
-	"image"
 )
 
 // Orientation enum representing the orientation of an object (Front, Left, Right)
@@ -35,6 +34,20 @@ func (o Orientation) String() string {
 	}
 }
 
+// orientationFromAngle picks the orientation facing closest to an angle in radians from the x-axis.
+func orientationFromAngle(rad float64) Orientation {
+	rotDeg := numerics.RadToDegree(rad)
+
+	if rotDeg >= -45 && rotDeg <= 45 {
+		return Right
+	} else if rotDeg >= 45 && rotDeg <= 135 {
+		return Front
+	} else if rotDeg >= 135 || rotDeg <= -135 {
+		return Left
+	}
+	return Back
+}
+
 // Object represents any game object which can be interactive
 type Object struct {
 	// The images representing the currently loaded object and its various orientations
@@ -55,15 +68,27 @@ type Object struct {
 	// The current rotation of the object
 	Rotation float64
 
-	// The count of the animation frame
-	Count int
-
 	// The current orientation of the image
 	Orientation Orientation
 
 	// Projectiles is a list of projectile objects fired by this object
 	Projectiles []*Projectile
 
+	// Health is the hit points of this object, objects without health cannot be damaged
+	Health *Health
+
+	// ContactDamage, when set, is dealt to the player whenever they touch this object
+	ContactDamage *Damage
+
+	// Trigger objects are not solid, anything overlapping them is reported with a TriggerEvent instead
+	Trigger bool
+
+	// Statuses are the status effects on the object, nil until one is applied
+	Statuses *Statuses
+
+	// Animator picks the frame to draw for animated objects, when it is nil the first frame of the image is drawn
+	Animator *animation.Animator
+
 	*AABB
 }
 
@@ -85,7 +110,7 @@ func NewObjectFromImages(images map[Orientation]*animation.Image) *Object {
 		Image:       images,
 		Op:          &ebiten.DrawImageOptions{},
 		Center:      center,
-		Velocity:    numerics.OneVec2(),
+		Velocity:    numerics.ZeroVec2(),
 		AABB:        aabb,
 		Orientation: orientation,
 		Projectiles: make([]*Projectile, 0),
@@ -98,64 +123,140 @@ func (o *Object) UpdatePosition(diff numerics.Vec2) {
 	o.AABB.UpdatePosition(diff)
 }
 
-func (o *Object) FireProjectile(direction numerics.Vec2, img *animation.Image) {
+// MoveAndCollide moves the object by its velocity for one tick, stopping at the room boundary and at any other object
+// it collides with. Velocity along a blocked axis is cancelled so the object does not keep pushing into the obstacle.
+// It returns the distance actually moved.
+func (o *Object) MoveAndCollide(room *Room, objects []*Object) numerics.Vec2 {
+	// Check if the object is colliding with the boundary of the room.
+	diff, blocked := room.CheckCollisionAndUpdatePosition(o, o.Velocity)
+
+	// Depending on the collision axis, prevent movement in diff
+	if o.IsColliding {
+		// Keep track of the previous diff so we can undo it
+		oldDiff := diff
+
+		// First, apply the diff to the position
+		o.UpdatePosition(oldDiff)
+
+		// Does this move relieve the collision?
+		anyCollision := false
+		for _, a := range objects {
+			if a == o || a.Trigger {
+				continue
+			}
+
+			// Check for a bounding box collision
+			if o.IsExternallyColliding2D(a.AABB) {
+				anyCollision = true
+			}
+		}
+
+		// If any of these are colliding, restrict the motion along the collision axis
+		if anyCollision {
+			if o.CollisionDirection.X {
+				diff = numerics.NewVec2(0, diff.Y())
+				blocked.X = true
+			}
+
+			if o.CollisionDirection.Y {
+				diff = numerics.NewVec2(diff.X(), 0)
+				blocked.Y = true
+			}
+		}
+
+		// Undo the position update.
+		o.UpdatePosition(oldDiff.MulScalar(-1))
+	}
+
+	// Whatever was blocked no longer contributes to the velocity
+	vx, vy := o.Velocity.X(), o.Velocity.Y()
+	if blocked.X {
+		vx = 0
+	}
+	if blocked.Y {
+		vy = 0
+	}
+	o.Velocity = numerics.NewVec2(vx, vy)
+
+	o.UpdatePosition(diff)
+	return diff
+}
+
+func (o *Object) FireProjectile(direction numerics.Vec2, img *animation.Image, damage Damage) {
 	// Make sure the direction is a normal vector
 	direction = direction.Normalized()
 
 	// Create a new projectile
-	p := NewProjectile(o, direction, img)
+	p := NewProjectile(o, direction, img, damage)
 
 	o.Projectiles = append(o.Projectiles, p)
 }
 
+// IsDead reports whether the object has health and has run out of it.
+func (o *Object) IsDead() bool {
+	return o.Health != nil && o.Health.IsDead()
+}
+
 func (o *Object) Render(screen *ebiten.Image, cameraTransform *ebiten.GeoM) {
-	img := o.Image[o.Orientation]
+	o.drawSprite(screen, cameraTransform)
 
-	// First, quick check if an image for "All" is set, if it is, always use that
-	if img == nil {
-		img = o.Image[All]
+	// Draw the player's bounding box
+	o.AABB.Render(screen, &o.Op.GeoM)
+}
+
+// drawSprite draws the current animation frame upright using the object's draw options. Only the GeoM is reset, so
+// any color scaling set on Op is kept.
+func (o *Object) drawSprite(screen *ebiten.Image, cameraTransform *ebiten.GeoM) {
+	frame := o.frame()
+	if frame == nil {
+		return
 	}
 
-	// First, rotate BEFORE any translation has occurred, we MUST create a new geom every time.
+	// We MUST create a new geom every time
 	o.Op.GeoM = ebiten.GeoM{}
 
-	if o.Orientation == Left {
-		// Left to right flips over the y axis
-		o.Op.GeoM.Scale(1.0, -1.0)
-		o.Op.GeoM.Translate(0, float64(img.FrameHeight))
-	}
-
-	if o.Orientation == Back {
-		// TODO: This doesn't really fix the issue
+	// Clips standing in for their mirror image are flipped left to right
+	if o.Animator != nil && o.Animator.Mirrored() {
 		o.Op.GeoM.Scale(-1.0, 1.0)
-		o.Op.GeoM.Translate(float64(img.FrameWidth), 0)
+		o.Op.GeoM.Translate(float64(frame.Bounds().Dx()), 0)
 	}
 
-	// Translate to the center of the object
-	o.Op.GeoM.Translate(-float64(img.FrameWidth)/2, -float64(img.FrameHeight)/2)
-
-	// Apply rotation
-	o.Op.GeoM.Rotate(o.Rotation)
-
-	// Translate back to the original position
-	o.Op.GeoM.Translate(float64(img.FrameWidth)/2, float64(img.FrameHeight)/2)
-
 	// Now, apply the camera transformation to this
 	o.Op.GeoM.Concat(*cameraTransform)
 
 	// Move to the object position including any camera offset
 	o.Op.GeoM.Translate(o.Position.X(), o.Position.Y())
 
-	// This just chooses the character frame from the sprite sheet. We divide by 5 so that way the transition
-	// between animation frames is less intense.
-	i := (o.Count / 10) % img.FrameCount
-	//sx, sy := img.FrameOX+i*img.FrameWidth, img.FrameOY
-	sx, sy := img.FrameOX, img.FrameOY+i*img.FrameHeight
+	// Status effects tint the sprite on top of whatever color scaling it already has
+	colorScale := o.Op.ColorScale
+	if tint, ok := o.Statuses.Tint(); ok {
+		o.Op.ColorScale.Scale(tint[0], tint[1], tint[2], 1)
+	}
 
-	screen.DrawImage(img.SubImage(image.Rect(sx, sy, sx+img.FrameWidth, sy+img.FrameHeight)).(*ebiten.Image), o.Op)
+	screen.DrawImage(frame, o.Op)
+	o.Op.ColorScale = colorScale
+}
 
-	// Draw the player's bounding box
-	o.AABB.Render(screen, &o.Op.GeoM)
+// frame returns the image to draw this tick. Objects with an animator show its current frame, anything else shows the
+// first frame of the image for its orientation.
+func (o *Object) frame() *ebiten.Image {
+	if o.Animator != nil {
+		return o.Animator.Frame()
+	}
+
+	img := o.Image[o.Orientation]
+
+	// First, quick check if an image for "All" is set, if it is, always use that
+	if img == nil {
+		img = o.Image[All]
+	}
+
+	// Anything without an image for its orientation is drawn facing front
+	if img == nil {
+		img = o.Image[Front]
+	}
+
+	return img.Frame(0)
 }
 
 // IsCollidingInternal implements the collidable interface for the Object
@@ -6,30 +6,46 @@ import (
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
+// ProjectileSpeed is how far a projectile travels each tick
+const ProjectileSpeed = 2
+
 type Projectile struct {
 	// Direction is the direction the projectile is moving in.
 	Direction numerics.Vec2
 
+	// Owner is the object that fired the projectile, a projectile never hits its owner.
+	Owner *Object
+
+	// Damage is dealt to whatever the projectile hits.
+	Damage Damage
+
+	// Hostile projectiles were fired by enemies, they only hit the player.
+	Hostile bool
+
+	// Spent is set once the projectile has hit something or left the room and should be removed.
+	Spent bool
+
 	*Object
 }
 
-func NewProjectile(src *Object, direction numerics.Vec2, img *animation.Image) *Projectile {
-	aabb := NewAABB(numerics.ZeroVec2(), img)
+func NewProjectile(src *Object, direction numerics.Vec2, img *animation.Image, damage Damage) *Projectile {
+	// The bounding box has to start where the projectile does or it will never line up with what it hits
+	aabb := NewAABB(src.Position, img)
 
 	obj := &Object{
 		Image:       map[Orientation]*animation.Image{All: img},
 		Op:          &ebiten.DrawImageOptions{},
 		Position:    src.Position,
 		Center:      src.Center,
-		Velocity:    numerics.OneVec2().MulScalar(2),
+		Velocity:    direction.MulScalar(ProjectileSpeed),
 		AABB:        aabb,
 		Orientation: All,
 	}
 
-	return &Projectile{Direction: direction, Object: obj}
+	damage.Source = src
+	return &Projectile{Direction: direction, Owner: src, Damage: damage, Object: obj}
 }
 
 func (p *Projectile) Step() {
-	diff := p.Direction.Mul(p.Velocity)
-	p.UpdatePosition(diff)
+	p.UpdatePosition(p.Velocity)
 }
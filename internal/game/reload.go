@@ -0,0 +1,150 @@
+package game
+
+import (
+	"dungeon/internal/assets"
+	"dungeon/internal/stats"
+	"go.uber.org/zap"
+	"io/fs"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// reloadInterval is the number of ticks between checks for changed files
+const reloadInterval = 60
+
+// HotReload watches the assets during development, so changes to tuning, sprite sheets and animations show up in the
+// running game without a restart.
+type HotReload struct {
+	data  fs.FS
+	watch *assets.Watcher
+}
+
+// NewHotReload watches the assets directory, reloading definitions from data when any under data/ change.
+func NewHotReload(dir, data fs.FS) (*HotReload, error) {
+	watch, err := assets.NewWatcher(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &HotReload{data: data, watch: watch}, nil
+}
+
+// hotReload reloads whatever has changed on disk. Changed images and fonts are loaded again, and changed data reloads
+// every definition. Anything which fails to load is logged and the game carries on with what it had.
+func (g *Game) hotReload() {
+	defs := g.CurrentLevel.Defs
+
+	changed, err := g.HotReload.watch.Poll()
+	if err != nil {
+		zap.L().Error("Failed to check assets for changes", zap.Error(err))
+	}
+
+	var images, data []string
+	for _, name := range changed {
+		if strings.HasPrefix(name, "data/") {
+			data = append(data, name)
+		} else {
+			images = append(images, name)
+		}
+	}
+
+	if len(images) == 0 && len(data) == 0 {
+		return
+	}
+
+	for _, name := range images {
+		defs.Assets.Invalidate(name)
+	}
+
+	if entry, ok := defs.Assets.Manifest()[fontAsset]; ok && slices.Contains(images, entry.Path) {
+		if font, err := readFont(defs.Assets); err == nil {
+			g.Font = font
+		} else {
+			zap.L().Error("Failed to reload font", zap.String("font", fontAsset), zap.Error(err))
+		}
+	}
+
+	if len(data) > 0 {
+		next, err := LoadDefinitions(g.HotReload.data, defs.Assets)
+		if err != nil {
+			zap.L().Error("Failed to reload definitions", zap.Strings("files", data), zap.Error(err))
+			return
+		}
+		defs.update(next)
+	} else if err := defs.resolveSprites(); err != nil {
+		zap.L().Error("Failed to reload sprites", zap.Strings("files", images), zap.Error(err))
+		return
+	}
+
+	g.PlayerCharacter.retune()
+	g.reanimate()
+	zap.L().Info("Reloaded assets", zap.Strings("images", images), zap.Strings("data", data))
+}
+
+// reanimate gives the player and every enemy a new animator from their reloaded sprite.
+func (g *Game) reanimate() {
+	if a, err := newCharacterAnimator(g.PlayerCharacter.Def.sprite); err == nil {
+		g.PlayerCharacter.Animator = a
+	} else {
+		zap.L().Error("Failed to reload the player's animations", zap.Error(err))
+	}
+
+	for _, e := range g.Enemies {
+		if a, err := newCharacterAnimator(e.Def.sprite); err == nil {
+			e.Animator = a
+		} else {
+			zap.L().Error("Failed to reload enemy animations", zap.String("enemy", e.Def.Name), zap.Error(err))
+		}
+	}
+}
+
+// update takes on the reloaded definitions. Each one is copied over the definition it replaces rather than swapped for
+// it, so enemies, items and anything else already pointing at a definition see the new values.
+func (d *Definitions) update(next *Definitions) {
+	d.Characters = updateDefs(d.Characters, next.Characters)
+	d.Enemies = updateDefs(d.Enemies, next.Enemies)
+	d.Bosses = updateDefs(d.Bosses, next.Bosses)
+	d.Encounters = updateDefs(d.Encounters, next.Encounters)
+	d.Items = updateDefs(d.Items, next.Items)
+	d.Spells = updateDefs(d.Spells, next.Spells)
+	d.Statuses = updateDefs(d.Statuses, next.Statuses)
+	d.Patterns = updateDefs(d.Patterns, next.Patterns)
+	d.Sprites = updateDefs(d.Sprites, next.Sprites)
+	d.Loot = next.Loot
+	d.Behaviors = next.Behaviors
+}
+
+// updateDefs copies each definition in next over the one with the same name in current, returning next pointing at
+// the updated definitions. Definitions which were removed are dropped, anything still holding one keeps its old
+// values.
+func updateDefs[T any](current, next map[string]*T) map[string]*T {
+	for name, def := range next {
+		if old, ok := current[name]; ok {
+			*old = *def
+			next[name] = old
+		}
+	}
+	return next
+}
+
+// retune applies the character's definition again after it has been reloaded. Health, mana, items and modifiers are
+// kept.
+func (c *PlayerCharacter) retune() {
+	def := c.Def
+	c.Movement = def.Movement
+	c.Dash.DashDef = def.Dash
+	c.Health.InvulnerabilityTicks = SecondsToTicks(def.Health.Invulnerability)
+
+	c.baseAttack = def.Attack
+	c.baseResistances = make(map[DamageType]float64, len(def.Health.Resistances))
+	maps.Copy(c.baseResistances, def.Health.Resistances)
+
+	for stat, v := range def.Stats {
+		c.Stats.SetBase(stat, v)
+	}
+	c.Stats.SetBase(stats.MoveSpeed, def.Movement.MaxSpeed)
+	c.Stats.SetBase(stats.MaxHealth, def.Health.Max)
+
+	// Equipment is applied on top of the base attack, and sets the damage stat from it
+	c.applyEquipment()
+}
@@ -2,18 +2,23 @@ package game
 
 import (
 	"dungeon/internal/animation"
+	"dungeon/internal/nav"
 	"dungeon/internal/numerics"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/vector"
 	"image/color"
+	"math"
 	"math/rand"
 )
 
 // Door is a door that the player character can pass through
 type Door struct {
-	// To is the pointer that this door connects to
+	// To is the pointer that this door connects to, a nil room is the exit from the level
 	To *Room
 
+	// Locked doors can't be passed through
+	Locked bool
+
 	*Object
 }
 
@@ -29,12 +34,27 @@ func NewDoor(position numerics.Vec2, to *Room, doorImage *animation.Image) *Door
 	}
 }
 
+// Lock stops the door from being used and tints it red so the player can tell.
+func (d *Door) Lock() {
+	d.Locked = true
+	d.Op.ColorScale.Reset()
+	d.Op.ColorScale.Scale(1, 0.2, 0.2, 1)
+}
+
+func (d *Door) Unlock() {
+	d.Locked = false
+	d.Op.ColorScale.Reset()
+}
+
 type Room struct {
 	Layers [][]*Tile
 
 	// IsBossRoom just determines if this room needs to load a boss.
 	IsBossRoom bool
 
+	// Boss is the boss of a boss room. It is kept out of Enemies until the player first enters the room.
+	Boss *Enemy
+
 	// Position is the position of the top-level corner of the rectangle.
 	Position numerics.Vec2
 
@@ -44,14 +64,35 @@ type Room struct {
 	// Every room has at least one door
 	Doors []*Door
 
+	// Obstacles are solid objects inside the room, such as pillars, which block movement and sight
+	Obstacles []*Object
+
+	// Enemies are the enemies living in this room
+	Enemies []*Enemy
+
+	// Pickups are the items lying on the floor of the room
+	Pickups []*Pickup
+
+	// Spawner sends the room's waves of enemies in, nil if the room has no encounter
+	Spawner *Spawner
+
+	// Cleared is set once the room's encounter or boss has been beaten, the room stays open from then on
+	Cleared bool
+
 	// Color is the color of the boundary box of the room
 	Color color.Color
 
 	// StrokeWidth is the width of the stroke of the boundary box
 	StrokeWidth float32
+
+	// navGrid is built from the room's tiles and obstacles the first time something needs to navigate the room
+	navGrid *nav.Grid
 }
 
-func NewRoom(position, dimensions numerics.Vec2) *Room {
+// navAgentRadius is how far the center of a walking character has to stay from anything solid, half a sprite's width.
+const navAgentRadius = 12
+
+func NewRoom(rng *rand.Rand, position, dimensions numerics.Vec2) *Room {
 	// TODO Add layers
 	// The tiles needed to cover the floor
 	//tilesNeeded := nTilesNeeded(int(dimensions.X() * dimensions.Y()))
@@ -65,13 +106,15 @@ func NewRoom(position, dimensions numerics.Vec2) *Room {
 		Position:   position,
 		Dimensions: dimensions,
 
-		Doors: make([]*Door, 0),
+		Doors:     make([]*Door, 0),
+		Obstacles: make([]*Object, 0),
+		Enemies:   make([]*Enemy, 0),
 
 		// Random fill color
 		Color: color.RGBA{
-			R: uint8(rand.Intn(255)),
-			G: uint8(rand.Intn(255)),
-			B: uint8(rand.Intn(255)),
+			R: uint8(rng.Intn(255)),
+			G: uint8(rng.Intn(255)),
+			B: uint8(rng.Intn(255)),
 			A: 0xff,
 		},
 
@@ -86,35 +129,132 @@ func (r *Room) Bounds() (numerics.Vec2, numerics.Vec2) {
 	return r.Position, r.Position.Add(r.Dimensions)
 }
 
-func (r *Room) CheckCollisionAndUpdatePosition(object *Object, diff numerics.Vec2) numerics.Vec2 {
-	newPos := object.Position.Add(diff)
-
+// InteriorBounds is the range of positions the object can occupy without overlapping the room's walls.
+func (r *Room) InteriorBounds(object *Object) (numerics.Vec2, numerics.Vec2) {
 	startBounds, endBounds := r.Bounds()
 	startBounds = startBounds.AddScalar(float64(r.StrokeWidth) / 2)
-	// Account for the stroke width
 	endBounds = endBounds.SubScalar(float64(r.StrokeWidth) / 2)
 	endBounds = endBounds.Sub(numerics.NewVec2(
 		float64(object.Image[Front].FrameWidth),
 		float64(object.Image[Front].FrameHeight),
 	))
+	return startBounds, endBounds
+}
+
+// NavGrid returns the navigation grid covering the inside of the room, building it on first use.
+func (r *Room) NavGrid() *nav.Grid {
+	if r.navGrid == nil {
+		r.navGrid = r.buildNavGrid()
+	}
+	return r.navGrid
+}
+
+// InvalidateNavGrid throws away the navigation grid so it is rebuilt, call it after changing tiles or obstacles.
+func (r *Room) InvalidateNavGrid() {
+	r.navGrid = nil
+}
+
+// buildNavGrid lays a tile-sized grid over the inside of the room. Cells are blocked where the center of a character
+// could not go: within navAgentRadius of a wall, a solid tile or an obstacle.
+func (r *Room) buildNavGrid() *nav.Grid {
+	inset := float64(r.StrokeWidth) / 2
+	origin := r.Position.AddScalar(inset)
+	size := r.Dimensions.SubScalar(2 * inset)
+
+	grid := nav.NewGrid(
+		origin,
+		TileSize,
+		int(math.Ceil(size.X()/TileSize)),
+		int(math.Ceil(size.Y()/TileSize)),
+	)
+
+	radius := numerics.NewVec2(navAgentRadius, navAgentRadius)
+	end := origin.Add(size)
+
+	// Keep clear of the walls
+	grid.BlockRect(origin, numerics.NewVec2(end.X(), origin.Y()+navAgentRadius))
+	grid.BlockRect(numerics.NewVec2(origin.X(), end.Y()-navAgentRadius), end)
+	grid.BlockRect(origin, numerics.NewVec2(origin.X()+navAgentRadius, end.Y()))
+	grid.BlockRect(numerics.NewVec2(end.X()-navAgentRadius, origin.Y()), end)
+
+	// Tiles cover the whole room, the same layout Render uses
+	worldSizeX := int(r.Dimensions.X() / TileSize)
+	for _, layer := range r.Layers {
+		for i, t := range layer {
+			if t == nil || !t.Solid {
+				continue
+			}
+
+			tileMin := r.Position.Add(numerics.NewVec2(float64(i%worldSizeX*TileSize), float64(i/worldSizeX*TileSize)))
+			grid.BlockRect(tileMin.Sub(radius), tileMin.AddScalar(TileSize).Add(radius))
+		}
+	}
+
+	for _, obstacle := range r.Obstacles {
+		grid.BlockRect(obstacle.Min.Sub(radius), obstacle.Max.Add(radius))
+	}
+
+	return grid
+}
+
+// Center is the middle of the room, where the player arrives.
+func (r *Room) Center() numerics.Vec2 {
+	return r.Position.Add(r.Dimensions.DivScalar(2))
+}
+
+// LockDoors locks every door out of the room.
+func (r *Room) LockDoors() {
+	for _, door := range r.Doors {
+		door.Lock()
+	}
+}
+
+func (r *Room) UnlockDoors() {
+	for _, door := range r.Doors {
+		door.Unlock()
+	}
+}
+
+// HasLineOfSight reports whether the straight line between two points is not blocked by any obstacle.
+func (r *Room) HasLineOfSight(from, to numerics.Vec2) bool {
+	for _, obstacle := range r.Obstacles {
+		if obstacle.IntersectsSegment(from, to) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckCollisionAndUpdatePosition clamps a move by diff to the inside of the room, returning the move which fits and
+// the axes it was cut short on.
+func (r *Room) CheckCollisionAndUpdatePosition(object *Object, diff numerics.Vec2) (numerics.Vec2, CollisionDirection) {
+	newPos := object.Position.Add(diff)
+	var clamped CollisionDirection
+
+	// Account for the stroke width
+	startBounds, endBounds := r.InteriorBounds(object)
 
 	// Check if the physics object is about to exceed the extent of the room
 	// Check for collision on the X-axis and update position
 	if newPos.X() < startBounds.X() {
 		newPos = numerics.NewVec2(startBounds.X(), newPos.Y())
+		clamped.X = true
 	} else if newPos.X() >= endBounds.X() {
 		newPos = numerics.NewVec2(endBounds.X(), newPos.Y())
+		clamped.X = true
 	}
 
 	// Check for collision on the Y-axis and update position
 	if newPos.Y() < startBounds.Y() {
 		newPos = numerics.NewVec2(newPos.X(), startBounds.Y())
+		clamped.Y = true
 	} else if newPos.Y() >= endBounds.Y() {
 		newPos = numerics.NewVec2(newPos.X(), endBounds.Y())
+		clamped.Y = true
 	}
 
 	// Update diff to reflect newPos
-	return newPos.Sub(object.Position)
+	return newPos.Sub(object.Position), clamped
 }
 
 func (r *Room) Render(screen *ebiten.Image, cameraTransform *ebiten.GeoM) {
@@ -144,6 +284,14 @@ func (r *Room) Render(screen *ebiten.Image, cameraTransform *ebiten.GeoM) {
 		door.Render(screen, cameraTransform)
 	}
 
+	for _, obstacle := range r.Obstacles {
+		obstacle.Render(screen, cameraTransform)
+	}
+
+	for _, pickup := range r.Pickups {
+		pickup.Render(screen, cameraTransform)
+	}
+
 	//for x := 0; x < worldSizeX; x++ {
 	//	for y := 0; y < worldSizeY; y++ {
 	//		t := r.Layers[0][x+y*worldSizeX]
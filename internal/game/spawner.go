@@ -0,0 +1,288 @@
+package game
+
+import (
+	"dungeon/internal/numerics"
+	"fmt"
+	"go.uber.org/zap"
+	"math"
+	"math/rand"
+	"slices"
+)
+
+// SpawnDef is a group of enemies of a single kind within a wave.
+type SpawnDef struct {
+	Enemy string `json:"enemy"`
+	Count int    `json:"count"`
+}
+
+// WaveDef is a set of enemies which arrive together.
+type WaveDef struct {
+	Spawns []SpawnDef `json:"spawns"`
+
+	// Delay is how long after the previous wave is cleared this wave arrives, in seconds.
+	Delay float64 `json:"delay"`
+}
+
+// EncounterDef is the data-driven layout of a room's fight: how many spawn points the room gets and the waves that
+// come out of them, one after another.
+type EncounterDef struct {
+	// Name is the key the definition was loaded under.
+	Name string `json:"-"`
+
+	SpawnPoints int       `json:"spawn_points"`
+	Waves       []WaveDef `json:"waves"`
+
+	// Loot names the loot table rolled in the middle of the room once it is cleared.
+	Loot string `json:"loot"`
+}
+
+func (d *EncounterDef) setName(name string) { d.Name = name }
+
+// validate checks that every enemy the encounter spawns and its loot table are defined.
+func (d *EncounterDef) validate(defs *Definitions) error {
+	for _, wave := range d.Waves {
+		for _, spawn := range wave.Spawns {
+			if _, ok := defs.Enemies[spawn.Enemy]; !ok {
+				return fmt.Errorf("encounter %s: unknown enemy %q", d.Name, spawn.Enemy)
+			}
+		}
+	}
+
+	if _, ok := defs.Loot[d.Loot]; d.Loot != "" && !ok {
+		return fmt.Errorf("encounter %s: unknown loot table %q", d.Name, d.Loot)
+	}
+	return nil
+}
+
+const (
+	// spawnPointRadius is the half size of the area kept clear around a spawn point, enough for any enemy to fit
+	spawnPointRadius = 16
+
+	// spawnRingSize is how many enemies fit around a spawn point when a wave has more enemies than points
+	spawnRingSize = 6
+)
+
+// Spawner runs a room's encounter, releasing each wave once the one before it has been killed.
+type Spawner struct {
+	Def *EncounterDef
+
+	// SpawnPoints are the centers of the places enemies appear.
+	SpawnPoints []numerics.Vec2
+
+	// Wave is the index of the wave in progress, or -1 before the first wave has arrived.
+	Wave int
+
+	// alive are the enemies of the current wave which have not been killed yet.
+	alive []*Enemy
+
+	// delay is the number of ticks until the next wave arrives.
+	delay int
+
+	// unused are the indices of the spawn points not yet used since every point was last used, drawn at random so no
+	// point is used twice before all of them have been.
+	unused []int
+
+	// rng drives the enemies the spawner creates, it is seeded from the level so waves are reproducible.
+	rng *rand.Rand
+}
+
+// NewSpawner creates a spawner for the room, placing its spawn points clear of obstacles and the room center.
+func NewSpawner(rng *rand.Rand, room *Room, def *EncounterDef) *Spawner {
+	s := &Spawner{
+		Def:  def,
+		Wave: -1,
+		rng:  rand.New(rand.NewSource(rng.Int63())),
+	}
+
+	for i := 0; i < def.SpawnPoints; i++ {
+		if point, ok := findSpawnPoint(rng, room); ok {
+			s.SpawnPoints = append(s.SpawnPoints, point)
+		}
+	}
+
+	// Without anywhere to spawn, fall back to the middle of the room's far wall
+	if len(s.SpawnPoints) == 0 {
+		s.SpawnPoints = append(s.SpawnPoints, room.Center().Sub(numerics.NewVec2(0, room.Dimensions.Y()/4)))
+	}
+
+	return s
+}
+
+// nextSpawnPoint draws a spawn point which hasn't been used since every point was last used.
+func (s *Spawner) nextSpawnPoint() int {
+	if len(s.unused) == 0 {
+		s.unused = s.rng.Perm(len(s.SpawnPoints))
+	}
+
+	point := s.unused[len(s.unused)-1]
+	s.unused = s.unused[:len(s.unused)-1]
+	return point
+}
+
+// Done reports whether every wave has arrived and been killed.
+func (s *Spawner) Done() bool {
+	return s.Wave == len(s.Def.Waves)-1 && len(s.alive) == 0
+}
+
+// String describes the progress of the encounter for debug output.
+func (s *Spawner) String() string {
+	return fmt.Sprintf("%s wave %d/%d, %d alive", s.Def.Name, s.Wave+1, len(s.Def.Waves), len(s.alive))
+}
+
+// spawnWave creates the enemies of the next wave and returns them.
+func (s *Spawner) spawnWave(defs *Definitions, room *Room) ([]*Enemy, error) {
+	s.Wave++
+	wave := s.Def.Waves[s.Wave]
+
+	// uses counts how many enemies of this wave each spawn point has had, later ones are spread around it
+	uses := make([]int, len(s.SpawnPoints))
+
+	spawned := make([]*Enemy, 0)
+	for _, spawn := range wave.Spawns {
+		def := defs.Enemies[spawn.Enemy]
+		for i := 0; i < spawn.Count; i++ {
+			enemy, err := NewEnemy(def, numerics.ZeroVec2(), rand.New(rand.NewSource(s.rng.Int63())))
+			if err != nil {
+				return nil, err
+			}
+
+			n := s.nextSpawnPoint()
+			point := s.SpawnPoints[n]
+			if uses[n] > 0 {
+				angle := float64(uses[n]-1) * 2 * math.Pi / spawnRingSize
+				spread := point.Add(numerics.NewVec2(math.Cos(angle), math.Sin(angle)).MulScalar(2 * spawnPointRadius))
+
+				// Stack on the spawn point itself rather than spawn inside a pillar or wall
+				if spawnPointClear(room, spread) {
+					point = spread
+				}
+			}
+			uses[n]++
+
+			position := point.Sub(enemy.Dimensions().DivScalar(2))
+			enemy.UpdatePosition(position)
+			enemy.Home = position
+			spawned = append(spawned, enemy)
+		}
+	}
+
+	s.alive = append(s.alive, spawned...)
+	return spawned, nil
+}
+
+// findSpawnPoint picks a random point inside the room with room for an enemy around it, away from obstacles and the
+// room center.
+func findSpawnPoint(rng *rand.Rand, room *Room) (numerics.Vec2, bool) {
+	start, end := spawnArea(room)
+
+	for attempt := 0; attempt < 20; attempt++ {
+		point := numerics.NewVec2(
+			start.X()+rng.Float64()*(end.X()-start.X()),
+			start.Y()+rng.Float64()*(end.Y()-start.Y()),
+		)
+
+		if spawnPointClear(room, point) {
+			return point, true
+		}
+	}
+
+	return numerics.Vec2{}, false
+}
+
+// spawnArea is the part of the room a spawn point can be centered in without the area around it crossing the walls.
+func spawnArea(room *Room) (numerics.Vec2, numerics.Vec2) {
+	start, end := room.Bounds()
+	inset := float64(room.StrokeWidth)/2 + spawnPointRadius
+	return start.AddScalar(inset), end.SubScalar(inset)
+}
+
+// spawnPointClear reports whether an enemy can appear at the point: inside the walls, away from the room center and
+// with no obstacle in the area around it.
+func spawnPointClear(room *Room, point numerics.Vec2) bool {
+	start, end := spawnArea(room)
+	if point.X() < start.X() || point.Y() < start.Y() || point.X() > end.X() || point.Y() > end.Y() {
+		return false
+	}
+
+	if point.Sub(room.Center()).Length() < spawnClearance {
+		return false
+	}
+
+	box := &AABB{Min: point.SubScalar(spawnPointRadius), Max: point.AddScalar(spawnPointRadius)}
+	return !slices.ContainsFunc(room.Obstacles, func(o *Object) bool {
+		return box.Overlaps(o.AABB)
+	})
+}
+
+// startEncounter seals the player into a room that has not been cleared yet.
+func (g *Game) startEncounter(room *Room) {
+	if room.Spawner == nil || room.Cleared {
+		return
+	}
+
+	s := room.Spawner
+	if s.Wave < 0 && len(s.Def.Waves) > 0 {
+		s.delay = SecondsToTicks(s.Def.Waves[0].Delay)
+	}
+
+	room.LockDoors()
+	zap.L().Debug("Encounter started", zap.String("encounter", room.Spawner.Def.Name), zap.Uint64("frame", g.Frame))
+}
+
+// stepEncounter sends in the next wave once the current one is dead, and clears the room after the last.
+func (g *Game) stepEncounter() error {
+	room := g.CurrentLevel.CurrentRoom()
+	s := room.Spawner
+	if s == nil || room.Cleared {
+		return nil
+	}
+
+	s.alive = slices.DeleteFunc(s.alive, (*Enemy).IsDead)
+	if len(s.alive) > 0 {
+		return nil
+	}
+
+	if s.Done() {
+		g.clearRoom(room)
+		return nil
+	}
+
+	if s.delay > 0 {
+		s.delay--
+		return nil
+	}
+
+	spawned, err := s.spawnWave(g.CurrentLevel.Defs, room)
+	if err != nil {
+		return err
+	}
+
+	for _, enemy := range spawned {
+		g.addEnemy(enemy)
+	}
+
+	if s.Wave+1 < len(s.Def.Waves) {
+		s.delay = SecondsToTicks(s.Def.Waves[s.Wave+1].Delay)
+	}
+
+	zap.L().Debug("Wave arrived", zap.String("encounter", s.Def.Name), zap.Int("wave", s.Wave), zap.Uint64("frame", g.Frame))
+	return nil
+}
+
+// clearRoom marks the room as cleared for good, opens its doors and announces it.
+func (g *Game) clearRoom(room *Room) {
+	room.Cleared = true
+	room.UnlockDoors()
+	g.Events.Publish(RoomClearedEvent{Room: room})
+}
+
+// onRoomCleared drops the encounter's loot in the middle of the room.
+func (g *Game) onRoomCleared(e RoomClearedEvent) {
+	zap.L().Info("Room cleared", zap.Int("room", g.CurrentLevel.currentRoom), zap.Uint64("frame", g.Frame))
+
+	if e.Room.Spawner == nil {
+		return
+	}
+
+	g.dropLoot(e.Room.Spawner.Def.Loot, e.Room.Center())
+}
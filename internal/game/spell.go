@@ -0,0 +1,409 @@
+package game
+
+import (
+	"dungeon/internal/animation"
+	"dungeon/internal/gfx"
+	"dungeon/internal/input"
+	"dungeon/internal/numerics"
+	"dungeon/internal/stats"
+	"errors"
+	"fmt"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"go.uber.org/zap"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// HotbarSize is the number of spells which can be ready to cast at once.
+const HotbarSize = 4
+
+// hotbarActions are the actions which cast each hotbar slot.
+var hotbarActions = [HotbarSize]input.Action{input.Spell1, input.Spell2, input.Spell3, input.Spell4}
+
+var (
+	// ErrNoSpell is returned when casting from an empty hotbar slot.
+	ErrNoSpell = errors.New("no spell in slot")
+
+	// ErrCasting is returned when starting a cast while another is in progress.
+	ErrCasting = errors.New("already casting")
+
+	// ErrOnCooldown is returned when casting a spell which has been cast too recently.
+	ErrOnCooldown = errors.New("spell is on cooldown")
+
+	// ErrNoMana is returned when casting a spell without the mana to pay for it.
+	ErrNoMana = errors.New("not enough mana")
+)
+
+// SpellKind is what a spell does when its cast completes.
+type SpellKind int
+
+const (
+	// ProjectileSpell fires a projectile towards the target.
+	ProjectileSpell SpellKind = iota
+
+	// AreaSpell damages everything within Radius of the target.
+	AreaSpell
+
+	// SelfSpell heals the caster and applies its modifiers to them for Duration.
+	SelfSpell
+)
+
+func (k SpellKind) String() string {
+	switch k {
+	case ProjectileSpell:
+		return "Projectile"
+	case AreaSpell:
+		return "Area"
+	case SelfSpell:
+		return "Self"
+	default:
+		return "Unknown"
+	}
+}
+
+func (k SpellKind) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(k.String())), nil
+}
+
+func (k *SpellKind) UnmarshalText(text []byte) error {
+	for sk := ProjectileSpell; sk <= SelfSpell; sk++ {
+		if strings.EqualFold(sk.String(), string(text)) {
+			*k = sk
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown spell kind %q", text)
+}
+
+// SpellDef is the data-driven definition of a spell. Durations are in seconds and speeds in pixels per tick.
+type SpellDef struct {
+	// Name is the key the definition was loaded under.
+	Name string `json:"-"`
+
+	DisplayName string    `json:"display_name"`
+	Description string    `json:"description"`
+	Kind        SpellKind `json:"kind"`
+
+	ManaCost float64 `json:"mana_cost"`
+	Cooldown float64 `json:"cooldown"`
+
+	// CastTime is how long the spell takes to go off after it is cast, a dash interrupts it.
+	CastTime float64 `json:"cast_time"`
+
+	// Damage is dealt by projectile and area spells.
+	Damage Damage `json:"damage"`
+
+	// Speed and Size are the speed and width of a projectile spell's projectile.
+	Speed float64 `json:"speed"`
+	Size  int     `json:"size"`
+
+	// Range is the furthest from the caster an area spell can be centered, and Radius the size of the area.
+	Range  float64 `json:"range"`
+	Radius float64 `json:"radius"`
+
+	// Heal is the health a self spell restores.
+	Heal float64 `json:"heal"`
+
+	// Modifiers are applied to the caster of a self spell for Duration.
+	Modifiers []stats.Modifier `json:"modifiers"`
+	Duration  float64          `json:"duration"`
+
+	// Color is the color of the spell's projectile, area and hotbar slot.
+	Color [3]float32 `json:"color"`
+
+	// image is shared by every projectile the spell fires, it is created on first use.
+	image *animation.Image
+}
+
+func (d *SpellDef) setName(name string) { d.Name = name }
+
+func (d *SpellDef) rgba(alpha uint8) color.RGBA {
+	return color.RGBA{
+		R: uint8(d.Color[0] * float32(alpha)),
+		G: uint8(d.Color[1] * float32(alpha)),
+		B: uint8(d.Color[2] * float32(alpha)),
+		A: alpha,
+	}
+}
+
+// projectileImage returns the image a projectile spell's projectiles are drawn with.
+func (d *SpellDef) projectileImage() *animation.Image {
+	if d.image == nil {
+		size := max(d.Size, 2)
+		d.image = animation.NewImageFromImage(ebiten.NewImage(size, size))
+		d.image.Fill(d.rgba(0xff))
+	}
+	return d.image
+}
+
+// Spellbook is the spells a character knows, the ones on their hotbar, and the mana they cast them with.
+type Spellbook struct {
+	Known  []*SpellDef
+	Hotbar [HotbarSize]*SpellDef
+
+	Mana float64
+
+	// cooldowns is the number of ticks until each spell, by name, can be cast again
+	cooldowns map[string]int
+
+	// casting is the spell being cast, nil when not casting
+	casting *SpellDef
+
+	// castTicks is the number of ticks left until the spell being cast goes off
+	castTicks int
+
+	// target is the world position the spell being cast is aimed at
+	target numerics.Vec2
+}
+
+// NewSpellbook creates a spellbook which knows the spells, putting the first of them on the hotbar, with full mana.
+func NewSpellbook(known []*SpellDef, mana float64) *Spellbook {
+	b := &Spellbook{Known: known, Mana: mana, cooldowns: make(map[string]int)}
+	copy(b.Hotbar[:], known)
+	return b
+}
+
+// Cooldown returns the fraction of the spell's cooldown still to go.
+func (b *Spellbook) Cooldown(spell *SpellDef) float64 {
+	total := SecondsToTicks(spell.Cooldown)
+	if total == 0 {
+		return 0
+	}
+	return float64(b.cooldowns[spell.Name]) / float64(total)
+}
+
+// Casting returns the spell being cast and how far through the cast it is, or nil when not casting.
+func (b *Spellbook) Casting() (*SpellDef, float64) {
+	if b.casting == nil {
+		return nil, 0
+	}
+
+	total := SecondsToTicks(b.casting.CastTime)
+	if total == 0 {
+		return b.casting, 1
+	}
+	return b.casting, 1 - float64(b.castTicks)/float64(total)
+}
+
+// Begin starts casting the spell in the hotbar slot at the target. Mana is only spent once the cast completes.
+func (b *Spellbook) Begin(slot int, target numerics.Vec2) error {
+	if slot < 0 || slot >= HotbarSize || b.Hotbar[slot] == nil {
+		return ErrNoSpell
+	}
+
+	spell := b.Hotbar[slot]
+	switch {
+	case b.casting != nil:
+		return fmt.Errorf("%s: %w", spell.DisplayName, ErrCasting)
+	case b.cooldowns[spell.Name] > 0:
+		return fmt.Errorf("%s: %w", spell.DisplayName, ErrOnCooldown)
+	case b.Mana < spell.ManaCost:
+		return fmt.Errorf("%s: %w", spell.DisplayName, ErrNoMana)
+	}
+
+	b.casting = spell
+	b.castTicks = SecondsToTicks(spell.CastTime)
+	b.target = target
+	return nil
+}
+
+// Cancel interrupts the cast in progress without spending anything.
+func (b *Spellbook) Cancel() {
+	b.casting = nil
+	b.castTicks = 0
+}
+
+// Step advances cooldowns and the cast in progress by one tick and regenerates mana, up to maxMana, at regen per
+// second.
+func (b *Spellbook) Step(maxMana, regen float64) {
+	b.Mana = min(b.Mana+regen/ebiten.DefaultTPS, maxMana)
+
+	for name, ticks := range b.cooldowns {
+		if ticks <= 1 {
+			delete(b.cooldowns, name)
+			continue
+		}
+		b.cooldowns[name] = ticks - 1
+	}
+
+	if b.casting != nil && b.castTicks > 0 {
+		b.castTicks--
+	}
+}
+
+// Finish completes the cast in progress once its cast time is up, spending its mana and starting its cooldown. It
+// returns the spell and its target, or nil if no cast completed this tick.
+func (b *Spellbook) Finish() (*SpellDef, numerics.Vec2) {
+	spell := b.casting
+	if spell == nil || b.castTicks > 0 {
+		return nil, numerics.Vec2{}
+	}
+
+	b.casting = nil
+	b.Mana -= spell.ManaCost
+	if ticks := SecondsToTicks(spell.Cooldown); ticks > 0 {
+		b.cooldowns[spell.Name] = ticks
+	}
+	return spell, b.target
+}
+
+// areaFlashTicks is how long the outline of an area spell stays on screen
+const areaFlashTicks = 15
+
+// areaFlash is the fading outline left where an area spell went off.
+type areaFlash struct {
+	spell  *SpellDef
+	center numerics.Vec2
+	ticks  int
+}
+
+// handleSpells starts casts for whichever hotbar keys were pressed this tick and resolves any cast which completed. It
+// must be called after the player moves.
+func (g *Game) handleSpells(state input.State) {
+	pc := g.PlayerCharacter
+	book := pc.Spellbook
+	book.Step(pc.Stats.Get(stats.MaxMana), pc.Stats.Get(stats.ManaRegen))
+
+	mx, my := g.Camera.ScreenToWorld(state.CursorX, state.CursorY)
+	target := numerics.NewVec2(mx, my)
+
+	for slot, action := range hotbarActions {
+		if pc.pressed&action == 0 {
+			continue
+		}
+
+		if err := book.Begin(slot, target); err != nil {
+			zap.L().Debug("Can't cast spell", zap.Int("slot", slot), zap.Error(err))
+		}
+	}
+
+	if spell, target := book.Finish(); spell != nil {
+		g.castSpell(spell, target)
+	}
+}
+
+// castSpell applies the effect of a completed spell cast by the player.
+func (g *Game) castSpell(spell *SpellDef, target numerics.Vec2) {
+	pc := g.PlayerCharacter
+	zap.L().Debug("Spell cast", zap.String("spell", spell.Name), zap.Uint64("frame", g.Frame))
+
+	switch spell.Kind {
+	case ProjectileSpell:
+		direction := target.Sub(pc.Center)
+		if direction.IsZero() {
+			direction = numerics.NewVec2(math.Cos(pc.Rotation), math.Sin(pc.Rotation))
+		}
+		direction = direction.Normalized()
+
+		img := spell.projectileImage()
+		offset := numerics.NewVec2(float64(img.FrameWidth), float64(img.FrameHeight)).DivScalar(2)
+
+		p := NewProjectile(pc.Object, direction, img, spell.Damage)
+		p.Velocity = direction.MulScalar(spell.Speed)
+
+		// Fire from the middle of the caster rather than their corner
+		p.UpdatePosition(pc.Center.Sub(offset).Sub(p.Position))
+		p.Center = pc.Center
+		pc.Projectiles = append(pc.Projectiles, p)
+
+	case AreaSpell:
+		// Spells aimed further away than their range go off at the edge of it
+		if toTarget := target.Sub(pc.Center); spell.Range > 0 && toTarget.Length() > spell.Range {
+			target = pc.Center.Add(toTarget.Normalized().MulScalar(spell.Range))
+		}
+
+		for _, o := range g.Objects {
+			if o == pc.Object || o.Health == nil || o.IsDead() {
+				continue
+			}
+
+			if o.Center.Sub(target).Length() <= spell.Radius {
+				damage := spell.Damage
+				damage.Source = pc.Object
+				g.ApplyDamage(o, damage)
+			}
+		}
+
+		g.flashes = append(g.flashes, &areaFlash{spell: spell, center: target, ticks: areaFlashTicks})
+
+	case SelfSpell:
+		pc.Health.Heal(spell.Heal)
+		if len(spell.Modifiers) > 0 {
+			pc.AddBuff("spell:"+spell.Name, SecondsToTicks(spell.Duration), spell.Modifiers...)
+		}
+	}
+}
+
+// stepFlashes fades out area spell outlines.
+func (g *Game) stepFlashes() {
+	flashes := g.flashes[:0]
+	for _, f := range g.flashes {
+		f.ticks--
+		if f.ticks > 0 {
+			flashes = append(flashes, f)
+		}
+	}
+	clear(g.flashes[len(flashes):])
+	g.flashes = flashes
+}
+
+// drawFlashes draws the outline of every recent area spell.
+func (g *Game) drawFlashes(screen *ebiten.Image, cameraTransform *ebiten.GeoM) {
+	for _, f := range g.flashes {
+		x, y := cameraTransform.Apply(f.center.X(), f.center.Y())
+		alpha := uint8(0xff * f.ticks / areaFlashTicks)
+		vector.StrokeCircle(screen, float32(x), float32(y), float32(f.spell.Radius), 3, f.spell.rgba(alpha), false)
+	}
+}
+
+const (
+	hotbarSlotSize = 40
+	hotbarGap      = 6
+	manaBarHeight  = 8
+)
+
+// drawHotbar draws the player's hotbar, their mana and the progress of any cast along the bottom of the screen.
+func (g *Game) drawHotbar(screen *ebiten.Image) {
+	book := g.PlayerCharacter.Spellbook
+
+	width := float32(HotbarSize*hotbarSlotSize + (HotbarSize-1)*hotbarGap)
+	x := (float32(gfx.ScreenWidth) - width) / 2
+	y := float32(gfx.ScreenHeight - hotbarSlotSize - 24)
+
+	for slot, spell := range book.Hotbar {
+		sx := x + float32(slot*(hotbarSlotSize+hotbarGap))
+		vector.DrawFilledRect(screen, sx, y, hotbarSlotSize, hotbarSlotSize, color.RGBA{R: 0x20, G: 0x20, B: 0x20, A: 0xff}, false)
+
+		if spell != nil {
+			fill := spell.rgba(0xff)
+			if book.Mana < spell.ManaCost {
+				fill = spell.rgba(0x60)
+			}
+			vector.DrawFilledRect(screen, sx+4, y+4, hotbarSlotSize-8, hotbarSlotSize-8, fill, false)
+
+			// Darken the part of the slot still cooling down, from the top
+			if cooldown := book.Cooldown(spell); cooldown > 0 {
+				vector.DrawFilledRect(screen, sx, y, hotbarSlotSize, float32(hotbarSlotSize*cooldown), color.RGBA{A: 0xb0}, false)
+			}
+		}
+
+		gfx.DrawText(screen, g.Font.Face(gfx.SmallText), fmt.Sprintf("%d", slot+1), int(sx)+3, int(y)+1, &gfx.TextOptions{Outline: color.Black})
+	}
+
+	manaY := y + hotbarSlotSize + 4
+	maxMana := g.PlayerCharacter.Stats.Get(stats.MaxMana)
+	vector.DrawFilledRect(screen, x, manaY, width, manaBarHeight, color.RGBA{B: 0x40, A: 0xff}, false)
+	if maxMana > 0 {
+		vector.DrawFilledRect(screen, x, manaY, width*float32(book.Mana/maxMana), manaBarHeight, color.RGBA{R: 0x30, G: 0x60, B: 0xf0, A: 0xff}, false)
+	}
+
+	if spell, progress := book.Casting(); spell != nil {
+		castY := y - manaBarHeight - 4
+		vector.DrawFilledRect(screen, x, castY, width, manaBarHeight, color.RGBA{R: 0x20, G: 0x20, B: 0x20, A: 0xff}, false)
+		vector.DrawFilledRect(screen, x, castY, width*float32(progress), manaBarHeight, spell.rgba(0xff), false)
+		face := g.Font.Face(gfx.SmallText)
+		gfx.DrawText(screen, face, spell.DisplayName, int(x), int(castY)-gfx.LineHeight(face), &gfx.TextOptions{Outline: color.Black})
+	}
+}
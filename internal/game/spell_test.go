@@ -0,0 +1,145 @@
+package game
+
+import (
+	"dungeon/internal/numerics"
+	"errors"
+	"testing"
+)
+
+var (
+	// testBolt takes half a second to cast and can be cast once a second.
+	testBolt = &SpellDef{Name: "bolt", DisplayName: "Bolt", ManaCost: 10, Cooldown: 1, CastTime: 0.5}
+
+	// testNova goes off straight away and has no cooldown.
+	testNova = &SpellDef{Name: "nova", DisplayName: "Nova", ManaCost: 30}
+)
+
+func TestSpellbookBegin(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(b *Spellbook)
+		slot  int
+		want  error
+	}{
+		{"ready", func(b *Spellbook) {}, 0, nil},
+		{"empty slot", func(b *Spellbook) {}, 2, ErrNoSpell},
+		{"slot past the hotbar", func(b *Spellbook) {}, HotbarSize, ErrNoSpell},
+		{"already casting", func(b *Spellbook) { _ = b.Begin(1, numerics.Vec2{}) }, 0, ErrCasting},
+		{"on cooldown", func(b *Spellbook) { b.cooldowns[testBolt.Name] = 1 }, 0, ErrOnCooldown},
+		{"not enough mana", func(b *Spellbook) { b.Mana = testBolt.ManaCost - 1 }, 0, ErrNoMana},
+		{"just enough mana", func(b *Spellbook) { b.Mana = testBolt.ManaCost }, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewSpellbook([]*SpellDef{testBolt, testNova}, 100)
+			tt.setup(b)
+			mana := b.Mana
+
+			if err := b.Begin(tt.slot, numerics.Vec2{}); !errors.Is(err, tt.want) {
+				t.Errorf("Begin = %v, want %v", err, tt.want)
+			}
+			if b.Mana != mana {
+				t.Errorf("mana = %v after starting a cast, want %v", b.Mana, mana)
+			}
+		})
+	}
+}
+
+func TestSpellbookCast(t *testing.T) {
+	tests := []struct {
+		name     string
+		slot     int
+		steps    int
+		cancel   bool
+		want     *SpellDef
+		mana     float64
+		cooldown float64
+		casting  bool
+	}{
+		{"cast time not up", 0, SecondsToTicks(testBolt.CastTime) - 1, false, nil, 100, 0, true},
+		{"cast complete", 0, SecondsToTicks(testBolt.CastTime), false, testBolt, 90, 1, false},
+		{"instant", 1, 0, false, testNova, 70, 0, false},
+		{"cancelled", 0, SecondsToTicks(testBolt.CastTime), true, nil, 100, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewSpellbook([]*SpellDef{testBolt, testNova}, 100)
+			target := numerics.NewVec2(3, 4)
+			if err := b.Begin(tt.slot, target); err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 0; i < tt.steps; i++ {
+				b.Step(100, 0)
+			}
+			if tt.cancel {
+				b.Cancel()
+			}
+
+			spell, at := b.Finish()
+			if spell != tt.want {
+				t.Fatalf("finished %v, want %v", spell, tt.want)
+			}
+			if spell != nil && at != target {
+				t.Errorf("target = %v, want %v", at, target)
+			}
+			if b.Mana != tt.mana {
+				t.Errorf("mana = %v, want %v", b.Mana, tt.mana)
+			}
+
+			// Only a completed cast starts the cooldown
+			if got := b.Cooldown(b.Hotbar[tt.slot]); got != tt.cooldown {
+				t.Errorf("cooldown = %v, want %v", got, tt.cooldown)
+			}
+			if casting, _ := b.Casting(); (casting != nil) != tt.casting {
+				t.Errorf("casting %v, want casting = %v", casting, tt.casting)
+			}
+		})
+	}
+}
+
+func TestSpellbookCooldown(t *testing.T) {
+	b := NewSpellbook([]*SpellDef{testNova, testBolt}, 100)
+	b.cooldowns[testBolt.Name] = SecondsToTicks(testBolt.Cooldown)
+
+	for i := 1; i < SecondsToTicks(testBolt.Cooldown); i++ {
+		b.Step(100, 0)
+	}
+	if err := b.Begin(1, numerics.Vec2{}); !errors.Is(err, ErrOnCooldown) {
+		t.Fatalf("Begin a tick before the cooldown is up = %v, want ErrOnCooldown", err)
+	}
+
+	b.Step(100, 0)
+	if err := b.Begin(1, numerics.Vec2{}); err != nil {
+		t.Errorf("Begin once the cooldown is up = %v", err)
+	}
+}
+
+func TestSpellbookRegen(t *testing.T) {
+	// At 60 mana a second a tick regenerates one mana
+	tests := []struct {
+		name    string
+		mana    float64
+		maxMana float64
+		regen   float64
+		want    float64
+	}{
+		{"regenerates", 50, 100, 60, 51},
+		{"capped at max", 99.5, 100, 60, 100},
+		{"already full", 100, 100, 60, 100},
+		{"max lowered", 120, 100, 0, 100},
+		{"no regen", 50, 100, 0, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewSpellbook(nil, tt.mana)
+			b.Step(tt.maxMana, tt.regen)
+			if b.Mana != tt.want {
+				t.Errorf("mana = %v, want %v", b.Mana, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package game
+
+import (
+	"fmt"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"go.uber.org/zap"
+	"image/color"
+	"slices"
+	"strings"
+)
+
+// StackRule is what happens when a status effect is applied to something which already has it.
+type StackRule int
+
+const (
+	// Refresh restarts the effect's duration.
+	Refresh StackRule = iota
+
+	// Stack adds a stack, up to MaxStacks, and restarts the duration. Damage is dealt once per stack.
+	Stack
+
+	// Extend adds the effect's duration to whatever is left of it.
+	Extend
+)
+
+func (r StackRule) String() string {
+	switch r {
+	case Refresh:
+		return "Refresh"
+	case Stack:
+		return "Stack"
+	case Extend:
+		return "Extend"
+	default:
+		return "Unknown"
+	}
+}
+
+func (r StackRule) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(r.String())), nil
+}
+
+func (r *StackRule) UnmarshalText(text []byte) error {
+	for sr := Refresh; sr <= Extend; sr++ {
+		if strings.EqualFold(sr.String(), string(text)) {
+			*r = sr
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown stack rule %q", text)
+}
+
+// StatusDef is the data-driven definition of a timed status effect. Durations are in seconds.
+type StatusDef struct {
+	// Name is the key the definition was loaded under.
+	Name string `json:"-"`
+
+	Duration float64 `json:"duration"`
+
+	// Damage is dealt every Interval for as long as the effect lasts, once for each stack.
+	Damage   *Damage `json:"damage"`
+	Interval float64 `json:"interval"`
+
+	// Slow is the fraction of movement speed lost, 1 stops movement entirely.
+	Slow float64 `json:"slow"`
+
+	// Stun stops the AI or player input of whatever has the effect.
+	Stun bool `json:"stun"`
+
+	Stacking  StackRule `json:"stacking"`
+	MaxStacks int       `json:"max_stacks"`
+
+	// Tint colors whatever has the effect, and its icon.
+	Tint [3]float32 `json:"tint"`
+}
+
+func (d *StatusDef) setName(name string) { d.Name = name }
+
+// Status is a status effect in progress.
+type Status struct {
+	Def    *StatusDef
+	Stacks int
+
+	// ticks is the number of ticks the effect has left.
+	ticks int
+
+	// elapsed is the number of ticks since the effect was first applied, damage falls on multiples of the interval.
+	elapsed int
+}
+
+// Statuses are the status effects on an object, in the order they were applied. A nil Statuses has no effects, so
+// objects only need one once something is applied to them.
+type Statuses struct {
+	Active []*Status
+}
+
+// Apply adds the effect, or follows its stacking rule if it is already active.
+func (s *Statuses) Apply(def *StatusDef) {
+	ticks := SecondsToTicks(def.Duration)
+
+	i := slices.IndexFunc(s.Active, func(st *Status) bool { return st.Def == def })
+	if i < 0 {
+		s.Active = append(s.Active, &Status{Def: def, Stacks: 1, ticks: ticks})
+		return
+	}
+
+	st := s.Active[i]
+	switch def.Stacking {
+	case Refresh:
+		st.ticks = ticks
+	case Stack:
+		st.Stacks = min(st.Stacks+1, max(def.MaxStacks, 1))
+		st.ticks = ticks
+	case Extend:
+		st.ticks += ticks
+	}
+}
+
+// Clear removes every effect.
+func (s *Statuses) Clear() {
+	if s != nil {
+		s.Active = nil
+	}
+}
+
+// Stunned reports whether any active effect stuns.
+func (s *Statuses) Stunned() bool {
+	if s == nil {
+		return false
+	}
+	return slices.ContainsFunc(s.Active, func(st *Status) bool { return st.Def.Stun })
+}
+
+// SpeedFactor is the fraction of normal movement speed left after the strongest active slow.
+func (s *Statuses) SpeedFactor() float64 {
+	if s == nil {
+		return 1
+	}
+
+	slow := 0.0
+	for _, st := range s.Active {
+		slow = max(slow, st.Def.Slow)
+	}
+	return max(1-slow, 0)
+}
+
+// Tint returns the tint of the most recently applied effect which has one.
+func (s *Statuses) Tint() ([3]float32, bool) {
+	if s == nil {
+		return [3]float32{}, false
+	}
+
+	for i := len(s.Active) - 1; i >= 0; i-- {
+		if tint := s.Active[i].Def.Tint; tint != [3]float32{} {
+			return tint, true
+		}
+	}
+	return [3]float32{}, false
+}
+
+// Step advances every effect by one tick, dropping any which have run out. It returns the damage due this tick.
+func (s *Statuses) Step() []Damage {
+	if s == nil {
+		return nil
+	}
+
+	damage := make([]Damage, 0)
+	active := s.Active[:0]
+	for _, st := range s.Active {
+		st.elapsed++
+		st.ticks--
+
+		if st.Def.Damage != nil && st.elapsed%max(SecondsToTicks(st.Def.Interval), 1) == 0 {
+			d := *st.Def.Damage
+			d.Amount *= float64(st.Stacks)
+			d.Periodic = true
+			damage = append(damage, d)
+		}
+
+		if st.ticks > 0 {
+			active = append(active, st)
+		}
+	}
+
+	clear(s.Active[len(active):])
+	s.Active = active
+	return damage
+}
+
+// ApplyStatus puts the status effect on the object.
+func (g *Game) ApplyStatus(target *Object, def *StatusDef) {
+	if target.IsDead() {
+		return
+	}
+
+	if target.Statuses == nil {
+		target.Statuses = &Statuses{}
+	}
+
+	target.Statuses.Apply(def)
+	g.Events.Publish(StatusAppliedEvent{Target: target, Status: def})
+}
+
+// onHitStatuses applies the status effects carried by a hit to whatever it hit.
+func (g *Game) onHitStatuses(e DamageEvent) {
+	// Ticks of damage over time never apply more effects
+	if e.Damage.Periodic {
+		return
+	}
+
+	for _, name := range e.Damage.Statuses {
+		def, ok := g.CurrentLevel.Defs.Statuses[name]
+		if !ok {
+			zap.L().Error("Unknown status effect", zap.String("status", name))
+			continue
+		}
+		g.ApplyStatus(e.Target, def)
+	}
+}
+
+// stepStatuses runs every object's status effects for one tick, dealing any damage over time they are due.
+func (g *Game) stepStatuses() {
+	for _, o := range g.Objects {
+		if o.Statuses == nil {
+			continue
+		}
+
+		if o.IsDead() {
+			o.Statuses.Clear()
+			continue
+		}
+
+		for _, damage := range o.Statuses.Step() {
+			g.ApplyDamage(o, damage)
+		}
+	}
+}
+
+const (
+	statusIconSize = 6
+	statusIconGap  = 2
+)
+
+// drawStatusIcons draws a small square in each active effect's tint above everything which has one.
+func (g *Game) drawStatusIcons(screen *ebiten.Image, cameraTransform *ebiten.GeoM) {
+	for _, o := range g.Objects {
+		if o.Statuses == nil || len(o.Statuses.Active) == 0 || o.IsDead() {
+			continue
+		}
+
+		width := len(o.Statuses.Active)*(statusIconSize+statusIconGap) - statusIconGap
+		x, y := cameraTransform.Apply(o.Center.X()-float64(width)/2, o.AABB.Min.Y()-statusIconSize-4)
+
+		for i, st := range o.Statuses.Active {
+			ix := float32(x) + float32(i*(statusIconSize+statusIconGap))
+			tint := color.RGBA{
+				R: uint8(st.Def.Tint[0] * 0xff),
+				G: uint8(st.Def.Tint[1] * 0xff),
+				B: uint8(st.Def.Tint[2] * 0xff),
+				A: 0xff,
+			}
+			vector.DrawFilledRect(screen, ix, float32(y), statusIconSize, statusIconSize, tint, false)
+
+			// Every extra stack adds a pip above the icon
+			for stack := 1; stack < st.Stacks; stack++ {
+				vector.DrawFilledRect(screen, ix+float32(stack-1)*2, float32(y)-3, 1, 2, tint, false)
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package game
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestStatusesApply(t *testing.T) {
+	// Every effect lasts a second, and is reapplied a third of the way through
+	tests := []struct {
+		name    string
+		rule    StackRule
+		max     int
+		applies int
+		stacks  int
+		ticks   int
+	}{
+		{"refresh", Refresh, 0, 2, 1, 60},
+		{"stack", Stack, 3, 2, 2, 60},
+		{"stack capped", Stack, 3, 5, 3, 60},
+		{"stack without a cap set", Stack, 0, 3, 1, 60},
+		{"extend", Extend, 0, 2, 1, 100},
+		{"extend twice", Extend, 0, 3, 1, 160},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := &StatusDef{Name: "burning", Duration: 1, Stacking: tt.rule, MaxStacks: tt.max}
+			s := &Statuses{}
+
+			s.Apply(def)
+			for i := 0; i < 20; i++ {
+				s.Step()
+			}
+			for i := 1; i < tt.applies; i++ {
+				s.Apply(def)
+			}
+
+			if len(s.Active) != 1 {
+				t.Fatalf("%d active effects, want 1", len(s.Active))
+			}
+			if st := s.Active[0]; st.Stacks != tt.stacks || st.ticks != tt.ticks {
+				t.Errorf("stacks = %d with %d ticks left, want %d with %d", st.Stacks, st.ticks, tt.stacks, tt.ticks)
+			}
+		})
+	}
+}
+
+func TestStatusesStep(t *testing.T) {
+	tests := []struct {
+		name    string
+		def     StatusDef
+		applies int
+		ticks   int
+		damage  []float64
+		active  bool
+	}{
+		{
+			name:   "damage every interval",
+			def:    StatusDef{Duration: 2, Damage: &Damage{Amount: 2}, Interval: 0.5},
+			ticks:  120,
+			damage: []float64{2, 2, 2, 2},
+		},
+		{
+			name:    "damage scales with stacks",
+			def:     StatusDef{Duration: 2, Damage: &Damage{Amount: 2}, Interval: 0.5, Stacking: Stack, MaxStacks: 3},
+			applies: 3,
+			ticks:   120,
+			damage:  []float64{6, 6, 6, 6},
+		},
+		{
+			name:   "still running",
+			def:    StatusDef{Duration: 2, Damage: &Damage{Amount: 2}, Interval: 0.5},
+			ticks:  119,
+			damage: []float64{2, 2, 2},
+			active: true,
+		},
+		{
+			name:   "damage every tick without an interval",
+			def:    StatusDef{Duration: 0.05, Damage: &Damage{Amount: 1}},
+			ticks:  5,
+			damage: []float64{1, 1, 1},
+		},
+		{
+			name:   "no damage",
+			def:    StatusDef{Duration: 1, Slow: 0.5},
+			ticks:  30,
+			active: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Statuses{}
+			for i := 0; i < max(tt.applies, 1); i++ {
+				s.Apply(&tt.def)
+			}
+
+			var damage []float64
+			for i := 0; i < tt.ticks; i++ {
+				for _, d := range s.Step() {
+					if !d.Periodic {
+						t.Errorf("tick %d: damage over time not marked periodic", i)
+					}
+					damage = append(damage, d.Amount)
+				}
+			}
+
+			if !slices.Equal(damage, tt.damage) {
+				t.Errorf("damage = %v, want %v", damage, tt.damage)
+			}
+			if active := len(s.Active) > 0; active != tt.active {
+				t.Errorf("active = %v, want %v", active, tt.active)
+			}
+		})
+	}
+
+	// The definition's damage is never changed by the stacks
+	def := &StatusDef{Duration: 1, Damage: &Damage{Amount: 2}, Stacking: Stack, MaxStacks: 2}
+	s := &Statuses{}
+	s.Apply(def)
+	s.Apply(def)
+	s.Step()
+	if def.Damage.Amount != 2 || def.Damage.Periodic {
+		t.Errorf("definition damage changed to %+v", *def.Damage)
+	}
+}
+
+func TestNilStatuses(t *testing.T) {
+	var s *Statuses
+	if s.Stunned() || s.SpeedFactor() != 1 || s.Step() != nil {
+		t.Error("a nil Statuses has effects")
+	}
+	if _, ok := s.Tint(); ok {
+		t.Error("a nil Statuses has a tint")
+	}
+}
@@ -0,0 +1,42 @@
+package game
+
+import (
+	"dungeon/internal/assets"
+	"dungeon/internal/gfx"
+	"github.com/hajimehoshi/ebiten/v2"
+	"go.uber.org/zap"
+	"image/color"
+)
+
+// fontAsset is the name in the asset manifest of the font the HUD and menus are drawn in
+const fontAsset = "vt323"
+
+// loadFont loads the HUD font at the sizes the game draws text in. If it can't be loaded the bitmap font is used, so
+// text still shows.
+func loadFont(manager *assets.Manager) *gfx.Font {
+	font, err := readFont(manager)
+	if err != nil {
+		zap.L().Error("Failed to load font, using the bitmap font", zap.String("font", fontAsset), zap.Error(err))
+		return gfx.BitmapFont()
+	}
+	return font
+}
+
+// readFont reads and parses the HUD font at the sizes the game draws text in.
+func readFont(manager *assets.Manager) (*gfx.Font, error) {
+	data, err := manager.Read(fontAsset)
+	if err != nil {
+		return nil, err
+	}
+	return gfx.NewFont(data, gfx.SmallText, gfx.NormalText, gfx.LargeText)
+}
+
+// drawBanner draws a large message across the middle of the screen.
+func (g *Game) drawBanner(screen *ebiten.Image, message string, clr color.Color) {
+	face := g.Font.Face(gfx.LargeText)
+	gfx.DrawText(screen, face, message, gfx.ScreenWidth/2, (gfx.ScreenHeight-gfx.LineHeight(face))/2, &gfx.TextOptions{
+		Align:   gfx.AlignCenter,
+		Color:   clr,
+		Outline: color.Black,
+	})
+}
@@ -0,0 +1,33 @@
+package game
+
+import (
+	"fmt"
+	imgui "github.com/gabstv/cimgui-go"
+	ebimgui "github.com/gabstv/ebiten-imgui/v3"
+)
+
+// atlasPreviewSize is the width and height pages are shown at in the debug view
+const atlasPreviewSize = 256
+
+// atlasTextureIDs are the imgui texture references for each page preview, they have to stay at the same address for as
+// long as the texture is in use
+var atlasTextureIDs []*int
+
+// drawAtlasDebug shows how full each texture atlas page is, with a preview of what has been packed into it.
+func (g *Game) drawAtlasDebug() {
+	imgui.Begin("Texture Atlas")
+	defer imgui.End()
+
+	for i, page := range g.CurrentLevel.Defs.Assets.Atlas().Pages() {
+		imgui.Text(fmt.Sprintf("Page %d: %d images, %.1f%% full", i, page.Count, page.Fill()*100))
+		imgui.ProgressBar(float32(page.Fill()))
+
+		for len(atlasTextureIDs) <= i {
+			atlasTextureIDs = append(atlasTextureIDs, new(int))
+		}
+
+		tid := imgui.TextureID(atlasTextureIDs[i])
+		ebimgui.GlobalManager().Cache.SetTexture(tid, page.Image)
+		Image(tid, imgui.NewVec2(atlasPreviewSize, atlasPreviewSize))
+	}
+}
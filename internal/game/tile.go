@@ -1,10 +1,10 @@
 package game
 
 import (
-	"dungeon/internal/animation"
+	"dungeon/internal/atlas"
 	"dungeon/internal/numerics"
+	"fmt"
 	"github.com/hajimehoshi/ebiten/v2"
-	"go.uber.org/zap"
 	"image"
 )
 
@@ -23,26 +23,52 @@ type Tile struct {
 	*ebiten.Image
 	// The index into the image
 	Index int
+	// Solid tiles cannot be walked through
+	Solid bool
 }
 
-func NewTileFromImage(imgBytes []byte, startX, startY, tileSize int) *Tile {
-	egImg, err := animation.LoadImage(imgBytes)
-	if err != nil {
-		zap.L().Fatal("Failed to load image", zap.Error(err))
-		return nil
+// Tileset cuts tiles out of a decoded source image. Each tile is packed into the texture atlas the first time it is
+// asked for.
+type Tileset struct {
+	// Name identifies the tileset's tiles in the atlas.
+	Name string
+
+	source   image.Image
+	textures *atlas.Atlas
+	tiles    map[image.Rectangle]*Tile
+}
+
+// NewTileset creates a tileset cutting tiles out of the source and packing them into the atlas.
+func NewTileset(name string, source image.Image, textures *atlas.Atlas) *Tileset {
+	return &Tileset{Name: name, source: source, textures: textures, tiles: make(map[image.Rectangle]*Tile)}
+}
+
+// Tile returns the tileSize square tile with its top left corner at startX, startY in the source image.
+func (ts *Tileset) Tile(startX, startY, tileSize int) (*Tile, error) {
+	rect := image.Rect(startX, startY, startX+tileSize, startY+tileSize)
+	if t, ok := ts.tiles[rect]; ok {
+		return t, nil
 	}
 
-	egImg = egImg.SubImage(image.Rect(
-		startX,
-		startY,
-		startX+tileSize,
-		startY+tileSize,
-	)).(*ebiten.Image)
+	if !rect.In(ts.source.Bounds()) {
+		return nil, fmt.Errorf("tileset %s: tile %v is outside the image", ts.Name, rect)
+	}
 
-	return &Tile{
-		Image: egImg,
-		Index: -1,
+	sub, ok := ts.source.(interface {
+		SubImage(r image.Rectangle) image.Image
+	})
+	if !ok {
+		return nil, fmt.Errorf("tileset %s: image can't be cut into tiles", ts.Name)
 	}
+
+	img, err := ts.textures.Add(fmt.Sprintf("%s %v", ts.Name, rect), sub.SubImage(rect))
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Tile{Image: img, Index: -1}
+	ts.tiles[rect] = t
+	return t, nil
 }
 
 func (t *Tile) Render(screen *ebiten.Image, cameraTransform *ebiten.GeoM, pos numerics.Vec2) {
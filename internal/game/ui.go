@@ -0,0 +1,49 @@
+package game
+
+import (
+	"dungeon/internal/stats"
+	"fmt"
+	imgui "github.com/gabstv/cimgui-go"
+)
+
+var (
+	// selectedItemColor highlights the stack the use and equip actions apply to
+	selectedItemColor = imgui.NewVec4(1, 0.85, 0.3, 1)
+
+	itemColor = imgui.NewVec4(1, 1, 1, 1)
+)
+
+// drawInventoryWindow lists what the player is carrying and has equipped, and their stats. It only displays the
+// inventory, every change to it goes through the player's input so that replays see it.
+func (g *Game) drawInventoryWindow() {
+	inv := g.PlayerCharacter.Inventory
+
+	imgui.Begin("Inventory")
+	defer imgui.End()
+
+	imgui.Text(fmt.Sprintf("%d/%d slots  (Tab select, E use, F equip)", len(inv.Stacks), inv.Capacity))
+	imgui.Separator()
+
+	for i, stack := range inv.Stacks {
+		color := itemColor
+		if i == inv.Selected {
+			color = selectedItemColor
+		}
+		imgui.TextColored(color, fmt.Sprintf("%s x%d", stack.Item.DisplayName, stack.Count))
+	}
+
+	imgui.Separator()
+	for slot := WeaponSlot; slot <= TrinketSlot; slot++ {
+		name := "-"
+		if item := inv.Equipped[slot]; item != nil {
+			name = item.DisplayName
+		}
+		imgui.Text(fmt.Sprintf("%s: %s", slot, name))
+	}
+
+	imgui.Separator()
+	sheet := g.PlayerCharacter.Stats
+	for _, stat := range stats.All() {
+		imgui.Text(fmt.Sprintf("%s: %.2f (base %.2f)", stat, sheet.Get(stat), sheet.Base(stat)))
+	}
+}
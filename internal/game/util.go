@@ -3,9 +3,25 @@ package game
 import (
 	"dungeon/internal/numerics"
 	"github.com/hajimehoshi/ebiten/v2"
+	"math"
+	"time"
 )
 
 func MousePosition() numerics.Vec2 {
 	x, y := ebiten.CursorPosition()
 	return numerics.NewVec2(float64(x), float64(y))
 }
+
+// TickDuration is the game time which passes in one tick at the current TPS.
+func TickDuration() time.Duration {
+	tps := ebiten.TPS()
+	if tps <= 0 {
+		tps = ebiten.DefaultTPS
+	}
+	return time.Second / time.Duration(tps)
+}
+
+// SecondsToTicks converts a duration in seconds to a whole number of simulation ticks.
+func SecondsToTicks(seconds float64) int {
+	return int(math.Round(seconds * ebiten.DefaultTPS))
+}
@@ -0,0 +1,67 @@
+package game
+
+import (
+	"dungeon/internal/numerics"
+	"github.com/hajimehoshi/ebiten/v2"
+	"image/color"
+	"math"
+)
+
+// weaponLength and weaponWidth are the size of the weapon drawn in a character's hand
+const (
+	weaponLength = 14
+	weaponWidth  = 2
+)
+
+var (
+	// weaponHand is where the hand holding the weapon sits relative to the middle of the sprite, when facing right
+	weaponHand = numerics.NewVec2(4, 3)
+
+	// unarmedColor is the color of the plain wand held with no weapon equipped
+	unarmedColor = [3]float32{0.55, 0.35, 0.2}
+
+	// weaponImages are the weapon images created so far, keyed by color
+	weaponImages = make(map[[3]float32]*ebiten.Image)
+)
+
+// weaponImage returns the image of a weapon with the color, pointing right with its grip at the left end.
+func weaponImage(c [3]float32) *ebiten.Image {
+	img, ok := weaponImages[c]
+	if !ok {
+		img = ebiten.NewImage(weaponLength, weaponWidth)
+		img.Fill(color.RGBA{R: uint8(c[0] * 0xff), G: uint8(c[1] * 0xff), B: uint8(c[2] * 0xff), A: 0xff})
+		weaponImages[c] = img
+	}
+	return img
+}
+
+// drawWeapon draws the equipped weapon in the character's hand, turned to point wherever they are aiming. The
+// character's sprite stays upright, only the weapon follows the aim.
+func (c *PlayerCharacter) drawWeapon(screen *ebiten.Image, cameraTransform *ebiten.GeoM) {
+	if c.IsDead() {
+		return
+	}
+
+	weaponColor := unarmedColor
+	if weapon := c.Inventory.Equipped[WeaponSlot]; weapon != nil {
+		weaponColor = weapon.Color
+	}
+
+	// The hand swaps sides with the way the character faces
+	hand := weaponHand
+	if math.Cos(c.Rotation) < 0 {
+		hand = numerics.NewVec2(-hand.X(), hand.Y())
+	}
+	offset := c.Center.Sub(c.Position).Add(hand)
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(0, -weaponWidth/2)
+	op.GeoM.Rotate(c.Rotation)
+	op.GeoM.Translate(offset.X(), offset.Y())
+
+	// The same camera and position transforms as the character's sprite
+	op.GeoM.Concat(*cameraTransform)
+	op.GeoM.Translate(c.Position.X(), c.Position.Y())
+
+	screen.DrawImage(weaponImage(weaponColor), op)
+}
@@ -1 +1,81 @@
 package gfx
+
+import (
+	"fmt"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"go.uber.org/zap"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/opentype"
+)
+
+// Text sizes in pixels used across the HUD and menus.
+const (
+	SmallText  = 16
+	NormalText = 24
+	LargeText  = 48
+)
+
+// printable are the characters whose glyphs are cached as soon as a face is created, so the first frame showing them
+// doesn't stall rasterising
+const printable = " !\"#$%&'()*+,-./0123456789:;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[\\]^_`abcdefghijklmnopqrstuvwxyz{|}~"
+
+// Font is a font which can be drawn at several sizes. A face is made for each size the first time it is asked for and
+// kept, so each glyph is only rasterised once per size.
+type Font struct {
+	// font is the parsed TrueType font, nil for the bitmap font
+	font *opentype.Font
+
+	faces map[float64]font.Face
+}
+
+// NewFont parses a TrueType font, creating faces for the sizes up front.
+func NewFont(data []byte, sizes ...float64) (*Font, error) {
+	parsed, err := opentype.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing font: %w", err)
+	}
+
+	f := &Font{font: parsed, faces: make(map[float64]font.Face)}
+	for _, size := range sizes {
+		if _, err := f.newFace(size); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// BitmapFont returns the built in 7x13 bitmap font, which is drawn at its one size whatever size is asked for. It is the
+// fallback for when a TrueType font can't be loaded.
+func BitmapFont() *Font {
+	return &Font{faces: make(map[float64]font.Face)}
+}
+
+// Face returns the face of the font at the size in pixels.
+func (f *Font) Face(size float64) font.Face {
+	if f.font == nil {
+		return basicfont.Face7x13
+	}
+
+	face, ok := f.faces[size]
+	if !ok {
+		var err error
+		if face, err = f.newFace(size); err != nil {
+			zap.L().Error("Failed to create font face, using the bitmap font", zap.Error(err))
+			return basicfont.Face7x13
+		}
+	}
+	return face
+}
+
+func (f *Font) newFace(size float64) (font.Face, error) {
+	// At 72 DPI a point is a pixel. Hinting is left off, it blurs pixel fonts.
+	face, err := opentype.NewFace(f.font, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingNone})
+	if err != nil {
+		return nil, fmt.Errorf("font size %v: %w", size, err)
+	}
+
+	text.CacheGlyphs(face, printable)
+	f.faces[size] = face
+	return face, nil
+}
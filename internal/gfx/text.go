@@ -0,0 +1,116 @@
+package gfx
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font"
+	"image/color"
+	"strings"
+)
+
+// Align is how lines of text sit horizontally against the x they are drawn at.
+type Align int
+
+const (
+	// AlignLeft starts each line at x.
+	AlignLeft Align = iota
+
+	// AlignCenter centers each line on x.
+	AlignCenter
+
+	// AlignRight ends each line at x.
+	AlignRight
+)
+
+// TextOptions are how text is drawn. The zero value draws white, left aligned text without an outline.
+type TextOptions struct {
+	Align Align
+
+	// Color is the color of the text, white when nil.
+	Color color.Color
+
+	// Outline, when set, is drawn a pixel out around every glyph so the text reads over anything behind it.
+	Outline color.Color
+
+	// Width wraps lines longer than it onto the next line, when more than 0.
+	Width int
+}
+
+// outlineOffsets are the directions the outline is drawn in around each glyph
+var outlineOffsets = [8][2]int{{-1, -1}, {0, -1}, {1, -1}, {-1, 0}, {1, 0}, {-1, 1}, {0, 1}, {1, 1}}
+
+// LineHeight is the distance in pixels between the tops of two lines of text in the face.
+func LineHeight(face font.Face) int {
+	return face.Metrics().Height.Ceil()
+}
+
+// Measure returns the width of the widest line of the text and the height of all its lines.
+func Measure(face font.Face, s string) (int, int) {
+	lines := strings.Split(s, "\n")
+	width := 0
+	for _, line := range lines {
+		width = max(width, font.MeasureString(face, line).Ceil())
+	}
+	return width, len(lines) * LineHeight(face)
+}
+
+// Wrap splits the text into lines no wider than width, breaking between words. Line breaks in the text are kept, and a
+// single word wider than width is left on a line of its own.
+func Wrap(face font.Face, s string, width int) []string {
+	lines := make([]string, 0)
+	for _, paragraph := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			next := word
+			if line != "" {
+				next = line + " " + word
+			}
+
+			if line != "" && font.MeasureString(face, next).Ceil() > width {
+				lines = append(lines, line)
+				next = word
+			}
+			line = next
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// DrawText draws the text with its top at y, lining it up against x by the options' alignment. Options may be nil.
+func DrawText(dst *ebiten.Image, face font.Face, s string, x, y int, options *TextOptions) {
+	if options == nil {
+		options = &TextOptions{}
+	}
+
+	clr := options.Color
+	if clr == nil {
+		clr = color.White
+	}
+
+	lines := strings.Split(s, "\n")
+	if options.Width > 0 {
+		lines = Wrap(face, s, options.Width)
+	}
+
+	// text.Draw places glyphs on their baseline, which sits the ascent below the top of the line
+	baseline := y + face.Metrics().Ascent.Ceil()
+	for _, line := range lines {
+		lineX := x
+		switch options.Align {
+		case AlignCenter:
+			lineX -= font.MeasureString(face, line).Ceil() / 2
+		case AlignRight:
+			lineX -= font.MeasureString(face, line).Ceil()
+		}
+
+		if options.Outline != nil {
+			for _, offset := range outlineOffsets {
+				text.Draw(dst, line, face, lineX+offset[0], baseline+offset[1], options.Outline)
+			}
+		}
+		text.Draw(dst, line, face, lineX, baseline, clr)
+
+		baseline += LineHeight(face)
+	}
+}
@@ -0,0 +1,61 @@
+package gfx
+
+import (
+	"golang.org/x/image/font/basicfont"
+	"slices"
+	"testing"
+)
+
+// Every glyph of the 7x13 face is 7 pixels wide, so widths below are counted in characters of 7.
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  []string
+	}{
+		{"fits", "the quick", 7 * 9, []string{"the quick"}},
+		{"breaks between words", "the quick brown fox", 7 * 9, []string{"the quick", "brown fox"}},
+		{"one word per line", "a b c", 7 * 2, []string{"a", "b", "c"}},
+		{"keeps newlines", "one\ntwo three", 7 * 20, []string{"one", "two three"}},
+		{"keeps blank lines", "one\n\ntwo", 7 * 20, []string{"one", "", "two"}},
+		{"collapses spaces", "one   two", 7 * 20, []string{"one two"}},
+		{"overlong word", "a abcdefghijkl b", 7 * 5, []string{"a", "abcdefghijkl", "b"}},
+		{"overlong first word", "abcdefghijkl", 7 * 5, []string{"abcdefghijkl"}},
+		{"empty", "", 7 * 5, []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Wrap(basicfont.Face7x13, tt.text, tt.width)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Wrap(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	face := basicfont.Face7x13
+	if got := LineHeight(face); got != 13 {
+		t.Fatalf("LineHeight = %d, want 13", got)
+	}
+
+	tests := []struct {
+		text          string
+		width, height int
+	}{
+		{"", 0, 13},
+		{"abc", 7 * 3, 13},
+		{"ab\nabcd\nabc", 7 * 4, 13 * 3},
+		{"abc\n", 7 * 3, 13 * 2},
+	}
+
+	for _, tt := range tests {
+		width, height := Measure(face, tt.text)
+		if width != tt.width || height != tt.height {
+			t.Errorf("Measure(%q) = %d, %d, want %d, %d", tt.text, width, height, tt.width, tt.height)
+		}
+	}
+}
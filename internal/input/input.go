@@ -0,0 +1,130 @@
+package input
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Action is a bit flag representing a single player intent during a frame.
+type Action uint32
+
+const (
+	MoveUp Action = 1 << iota
+	MoveDown
+	MoveLeft
+	MoveRight
+	Sprint
+	Fire
+	Dash
+	NextItem
+	UseItem
+	EquipItem
+
+	// Spell1 to Spell4 cast the spell in the matching hotbar slot
+	Spell1
+	Spell2
+	Spell3
+	Spell4
+)
+
+func (a Action) String() string {
+	switch a {
+	case MoveUp:
+		return "MoveUp"
+	case MoveDown:
+		return "MoveDown"
+	case MoveLeft:
+		return "MoveLeft"
+	case MoveRight:
+		return "MoveRight"
+	case Sprint:
+		return "Sprint"
+	case Fire:
+		return "Fire"
+	case Dash:
+		return "Dash"
+	case NextItem:
+		return "NextItem"
+	case UseItem:
+		return "UseItem"
+	case EquipItem:
+		return "EquipItem"
+	case Spell1:
+		return "Spell1"
+	case Spell2:
+		return "Spell2"
+	case Spell3:
+		return "Spell3"
+	case Spell4:
+		return "Spell4"
+	default:
+		return "Unknown"
+	}
+}
+
+// State is the complete set of player input for one frame. Everything the simulation reads from the player must go
+// through State so that a recording of it is enough to reproduce a run.
+type State struct {
+	// Actions is the set of actions held this frame.
+	Actions Action
+
+	// CursorX and CursorY are the position of the cursor in screen space.
+	CursorX int
+	CursorY int
+}
+
+// Pressed reports whether the action is held this frame.
+func (s State) Pressed(a Action) bool {
+	return s.Actions&a != 0
+}
+
+// Source produces one State per game tick.
+type Source interface {
+	Next() (State, error)
+}
+
+// Keyboard is a Source that reads live input from the keyboard and mouse.
+type Keyboard struct{}
+
+// keyBindings maps every action to the keys which trigger it.
+var keyBindings = map[Action][]ebiten.Key{
+	MoveUp:    {ebiten.KeyW, ebiten.KeyArrowUp},
+	MoveDown:  {ebiten.KeyS, ebiten.KeyArrowDown},
+	MoveLeft:  {ebiten.KeyA, ebiten.KeyArrowLeft},
+	MoveRight: {ebiten.KeyD, ebiten.KeyArrowRight},
+	Sprint:    {ebiten.KeyShiftLeft},
+	Dash:      {ebiten.KeySpace},
+	NextItem:  {ebiten.KeyTab},
+	UseItem:   {ebiten.KeyE},
+	EquipItem: {ebiten.KeyF},
+	Spell1:    {ebiten.KeyDigit1},
+	Spell2:    {ebiten.KeyDigit2},
+	Spell3:    {ebiten.KeyDigit3},
+	Spell4:    {ebiten.KeyDigit4},
+}
+
+// mouseBindings maps every action to the mouse buttons which trigger it.
+var mouseBindings = map[Action][]ebiten.MouseButton{
+	Fire: {ebiten.MouseButtonLeft},
+}
+
+func (k *Keyboard) Next() (State, error) {
+	var s State
+	for action, keys := range keyBindings {
+		for _, key := range keys {
+			if ebiten.IsKeyPressed(key) {
+				s.Actions |= action
+			}
+		}
+	}
+
+	for action, buttons := range mouseBindings {
+		for _, button := range buttons {
+			if ebiten.IsMouseButtonPressed(button) {
+				s.Actions |= action
+			}
+		}
+	}
+
+	s.CursorX, s.CursorY = ebiten.CursorPosition()
+	return s, nil
+}
@@ -0,0 +1,270 @@
+// Package loot rolls item drops from weighted loot tables. Tables only deal in item names, so they can be rolled from
+// the game or from tools which simulate thousands of rolls to check the odds.
+package loot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+)
+
+// Rarity is how rare a drop is meant to be. Deeper levels can make rarer drops more likely, see Table.DepthBonus.
+type Rarity int
+
+const (
+	Common Rarity = iota
+	Uncommon
+	Rare
+	Epic
+	Legendary
+)
+
+func (r Rarity) String() string {
+	switch r {
+	case Common:
+		return "Common"
+	case Uncommon:
+		return "Uncommon"
+	case Rare:
+		return "Rare"
+	case Epic:
+		return "Epic"
+	case Legendary:
+		return "Legendary"
+	default:
+		return "Unknown"
+	}
+}
+
+func (r Rarity) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(r.String())), nil
+}
+
+func (r *Rarity) UnmarshalText(text []byte) error {
+	for lr := Common; lr <= Legendary; lr++ {
+		if strings.EqualFold(lr.String(), string(text)) {
+			*r = lr
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown rarity %q", text)
+}
+
+// Entry is one possible result of a roll. It names either an item or another table to roll on.
+type Entry struct {
+	Item  string `json:"item"`
+	Table string `json:"table"`
+
+	// Weight is the entry's share of the table's rolls, relative to the other entries.
+	Weight float64 `json:"weight"`
+
+	// Min and Max bound how many of the item drop, or how many times the nested table is rolled. Both default to 1.
+	Min int `json:"min"`
+	Max int `json:"max"`
+
+	Rarity Rarity `json:"rarity"`
+
+	// MinDepth is the shallowest level the entry can drop on.
+	MinDepth int `json:"min_depth"`
+}
+
+// count picks how many of the entry drop.
+func (e *Entry) count(rng *rand.Rand) int {
+	lo := max(e.Min, 1)
+	hi := max(e.Max, lo)
+	return lo + rng.Intn(hi-lo+1)
+}
+
+// Table is the data-driven definition of a loot table.
+type Table struct {
+	// Name is the key the table was loaded under.
+	Name string `json:"-"`
+
+	// Guaranteed entries always drop, on top of whatever is rolled.
+	Guaranteed []Entry `json:"guaranteed"`
+
+	// Rolls is how many times an entry is picked from Entries, each pick is independent.
+	Rolls   int     `json:"rolls"`
+	Entries []Entry `json:"entries"`
+
+	// Nothing is the weight of a roll dropping nothing at all.
+	Nothing float64 `json:"nothing"`
+
+	// DepthBonus raises the weight of entries of each rarity by this fraction for every level below the first.
+	DepthBonus map[Rarity]float64 `json:"depth_bonus"`
+}
+
+// weight returns the weight of the entry on a level of the given depth.
+func (t *Table) weight(e *Entry, depth int) float64 {
+	if depth < e.MinDepth {
+		return 0
+	}
+	return max(e.Weight*(1+t.DepthBonus[e.Rarity]*float64(max(depth-1, 0))), 0)
+}
+
+// all returns the guaranteed entries followed by the rolled ones.
+func (t *Table) all() []Entry {
+	return append(slices.Clone(t.Guaranteed), t.Entries...)
+}
+
+// Drop is a number of one item.
+type Drop struct {
+	Item  string `json:"item"`
+	Count int    `json:"count"`
+}
+
+// Tables are loot tables keyed by name. Entries can name other tables, which are rolled in turn.
+type Tables map[string]*Table
+
+// Load parses a JSON object of loot tables keyed by name, checking that every nested table exists and that no table
+// contains itself.
+func Load(data []byte) (Tables, error) {
+	tables := make(Tables)
+	if err := json.Unmarshal(data, &tables); err != nil {
+		return nil, fmt.Errorf("loot: failed to parse tables: %w", err)
+	}
+
+	for name, table := range tables {
+		table.Name = name
+	}
+
+	if err := tables.validate(); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+// Names returns the names of the tables in sorted order.
+func (t Tables) Names() []string {
+	names := make([]string, 0, len(t))
+	for name := range t {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Items returns every item any table can drop, in sorted order.
+func (t Tables) Items() []string {
+	items := make([]string, 0)
+	for _, table := range t {
+		for _, e := range table.all() {
+			if e.Item != "" && !slices.Contains(items, e.Item) {
+				items = append(items, e.Item)
+			}
+		}
+	}
+	slices.Sort(items)
+	return items
+}
+
+func (t Tables) validate() error {
+	for _, name := range t.Names() {
+		table := t[name]
+		for _, e := range table.all() {
+			switch {
+			case (e.Item == "") == (e.Table == ""):
+				return fmt.Errorf("loot: table %s: an entry must name exactly one of an item or a table", name)
+			case e.Table != "" && t[e.Table] == nil:
+				return fmt.Errorf("loot: table %s: unknown table %q", name, e.Table)
+			case e.Weight < 0:
+				return fmt.Errorf("loot: table %s: negative weight", name)
+			case e.Max != 0 && e.Max < e.Min:
+				return fmt.Errorf("loot: table %s: max below min", name)
+			}
+		}
+
+		if err := t.checkCycle(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkCycle walks every table reachable from name, failing if it comes back around to one already on the path.
+func (t Tables) checkCycle(name string, path []string) error {
+	if slices.Contains(path, name) {
+		return fmt.Errorf("loot: tables nest in a loop: %s", strings.Join(append(path, name), " -> "))
+	}
+
+	path = append(path, name)
+	table := t[name]
+	for _, e := range table.all() {
+		if e.Table == "" {
+			continue
+		}
+		if err := t.checkCycle(e.Table, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ErrUnknownTable is returned when rolling a table which does not exist.
+var ErrUnknownTable = errors.New("loot: unknown table")
+
+// Roll rolls the named table for a level of the given depth, returning what dropped. Drops of the same item are
+// combined, in the order they were first rolled, so the result only depends on the state of rng.
+func (t Tables) Roll(rng *rand.Rand, name string, depth int) ([]Drop, error) {
+	table := t[name]
+	if table == nil {
+		return nil, fmt.Errorf("%w %q", ErrUnknownTable, name)
+	}
+
+	drops := make([]Drop, 0)
+	t.roll(rng, table, depth, &drops)
+	return drops, nil
+}
+
+func (t Tables) roll(rng *rand.Rand, table *Table, depth int, drops *[]Drop) {
+	for i := range table.Guaranteed {
+		e := &table.Guaranteed[i]
+		if depth >= e.MinDepth {
+			t.resolve(rng, e, depth, drops)
+		}
+	}
+
+	total := table.Nothing
+	for i := range table.Entries {
+		total += table.weight(&table.Entries[i], depth)
+	}
+
+	if total <= 0 {
+		return
+	}
+
+	for r := 0; r < table.Rolls; r++ {
+		pick := rng.Float64() * total
+		for i := range table.Entries {
+			e := &table.Entries[i]
+			pick -= table.weight(e, depth)
+			if pick < 0 {
+				t.resolve(rng, e, depth, drops)
+				break
+			}
+		}
+		// Falling off the end of the entries is the Nothing weight
+	}
+}
+
+// resolve adds the entry's drop, rolling a nested table as many times as the entry's count.
+func (t Tables) resolve(rng *rand.Rand, e *Entry, depth int, drops *[]Drop) {
+	n := e.count(rng)
+	if e.Table != "" {
+		for i := 0; i < n; i++ {
+			t.roll(rng, t[e.Table], depth, drops)
+		}
+		return
+	}
+
+	for i := range *drops {
+		if (*drops)[i].Item == e.Item {
+			(*drops)[i].Count += n
+			return
+		}
+	}
+	*drops = append(*drops, Drop{Item: e.Item, Count: n})
+}
@@ -0,0 +1,158 @@
+package nav
+
+import (
+	"container/heap"
+	"dungeon/internal/numerics"
+	"math"
+)
+
+// FindPath finds a path between two world positions with A*. The returned waypoints are smoothed so that consecutive
+// points have a clear line between them, the final waypoint is the goal itself. It returns false if no path exists.
+func (g *Grid) FindPath(from, to numerics.Vec2) ([]numerics.Vec2, bool) {
+	start, ok := g.nearestOpen(g.CellAt(from))
+	if !ok {
+		return nil, false
+	}
+
+	goal, ok := g.nearestOpen(g.CellAt(to))
+	if !ok {
+		return nil, false
+	}
+
+	cells, ok := g.findCells(start, goal)
+	if !ok {
+		return nil, false
+	}
+
+	cells = g.Smooth(cells)
+
+	path := make([]numerics.Vec2, 0, len(cells))
+	for _, c := range cells[1:] {
+		path = append(path, g.CellCenter(c))
+	}
+
+	// End exactly on the goal if it is reachable, rather than on the center of its cell
+	if goal == g.CellAt(to) {
+		if len(path) == 0 {
+			path = append(path, to)
+		} else {
+			path[len(path)-1] = to
+		}
+	}
+
+	return path, true
+}
+
+// findCells runs A* between two open cells and returns every cell along the path, including both ends.
+func (g *Grid) findCells(start, goal Cell) ([]Cell, bool) {
+	n := len(g.blocked)
+	cost := make([]float64, n)
+	parent := make([]int32, n)
+	closed := make([]bool, n)
+	for i := range cost {
+		cost[i] = math.Inf(1)
+		parent[i] = -1
+	}
+
+	startIdx, goalIdx := g.index(start), g.index(goal)
+	cost[startIdx] = 0
+
+	open := &openSet{}
+	heap.Push(open, openNode{index: startIdx, priority: octile(start, goal)})
+
+	for open.Len() > 0 {
+		node := heap.Pop(open).(openNode)
+		if closed[node.index] {
+			continue
+		}
+		closed[node.index] = true
+
+		if node.index == goalIdx {
+			return g.tracePath(parent, goalIdx), true
+		}
+
+		c := g.cell(node.index)
+		for _, d := range neighbors {
+			if !g.canStep(c, d) {
+				continue
+			}
+
+			next := Cell{c.X + d.X, c.Y + d.Y}
+			nextIdx := g.index(next)
+			if closed[nextIdx] {
+				continue
+			}
+
+			newCost := cost[node.index] + stepCost(d)
+			if newCost < cost[nextIdx] {
+				cost[nextIdx] = newCost
+				parent[nextIdx] = int32(node.index)
+				heap.Push(open, openNode{index: nextIdx, priority: newCost + octile(next, goal)})
+			}
+		}
+	}
+
+	return nil, false
+}
+
+func (g *Grid) tracePath(parent []int32, goal int) []Cell {
+	cells := make([]Cell, 0)
+	for i := int32(goal); i != -1; i = parent[i] {
+		cells = append(cells, g.cell(int(i)))
+	}
+
+	// The trace runs goal to start, flip it around
+	for i, j := 0, len(cells)-1; i < j; i, j = i+1, j-1 {
+		cells[i], cells[j] = cells[j], cells[i]
+	}
+
+	return cells
+}
+
+// Smooth removes every waypoint which can be skipped by walking in a straight line from the waypoint before it.
+func (g *Grid) Smooth(cells []Cell) []Cell {
+	if len(cells) <= 2 {
+		return cells
+	}
+
+	smoothed := []Cell{cells[0]}
+	anchor := 0
+	for i := 2; i < len(cells); i++ {
+		if !g.clearLine(cells[anchor], cells[i]) {
+			anchor = i - 1
+			smoothed = append(smoothed, cells[anchor])
+		}
+	}
+
+	return append(smoothed, cells[len(cells)-1])
+}
+
+// octile is the exact path length between two cells on an open eight-connected grid, it never overestimates.
+func octile(a, b Cell) float64 {
+	dx := float64(abs(a.X - b.X))
+	dy := float64(abs(a.Y - b.Y))
+	return max(dx, dy) + (math.Sqrt2-1)*min(dx, dy)
+}
+
+type openNode struct {
+	index    int
+	priority float64
+}
+
+// openSet is a min-heap of nodes ordered by priority.
+type openSet []openNode
+
+func (s openSet) Len() int           { return len(s) }
+func (s openSet) Less(i, j int) bool { return s[i].priority < s[j].priority }
+func (s openSet) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func (s *openSet) Push(x any) {
+	*s = append(*s, x.(openNode))
+}
+
+func (s *openSet) Pop() any {
+	old := *s
+	n := old[len(old)-1]
+	*s = old[:len(old)-1]
+	return n
+}
@@ -0,0 +1,101 @@
+package nav
+
+import (
+	"container/heap"
+	"dungeon/internal/numerics"
+	"math"
+)
+
+// FlowField points every reachable cell of a grid along the shortest path to a single goal. It costs one Dijkstra
+// search to build, after which any number of agents can look up their direction in constant time, which is far
+// cheaper than an A* query per agent when many are chasing the same target.
+type FlowField struct {
+	grid *Grid
+	goal numerics.Vec2
+
+	// next holds, for each cell, the index of the cell to move to next, or -1 if the goal cannot be reached.
+	next []int32
+}
+
+// FlowField builds a flow field towards the world position.
+func (g *Grid) FlowField(to numerics.Vec2) *FlowField {
+	n := len(g.blocked)
+	f := &FlowField{grid: g, goal: to, next: make([]int32, n)}
+	for i := range f.next {
+		f.next[i] = -1
+	}
+
+	goal, ok := g.nearestOpen(g.CellAt(to))
+	if !ok {
+		return f
+	}
+
+	cost := make([]float64, n)
+	for i := range cost {
+		cost[i] = math.Inf(1)
+	}
+
+	goalIdx := g.index(goal)
+	cost[goalIdx] = 0
+	f.next[goalIdx] = int32(goalIdx)
+
+	open := &openSet{}
+	heap.Push(open, openNode{index: goalIdx})
+
+	for open.Len() > 0 {
+		node := heap.Pop(open).(openNode)
+		if node.priority > cost[node.index] {
+			continue
+		}
+
+		c := g.cell(node.index)
+		for _, d := range neighbors {
+			// Moves are symmetric, so a cell that can step here can be reached by stepping back the other way
+			if !g.canStep(c, d) {
+				continue
+			}
+
+			prevIdx := g.index(Cell{c.X + d.X, c.Y + d.Y})
+			newCost := cost[node.index] + stepCost(d)
+			if newCost < cost[prevIdx] {
+				cost[prevIdx] = newCost
+				f.next[prevIdx] = int32(node.index)
+				heap.Push(open, openNode{index: prevIdx, priority: newCost})
+			}
+		}
+	}
+
+	return f
+}
+
+// Goal is the position the field leads to.
+func (f *FlowField) Goal() numerics.Vec2 {
+	return f.goal
+}
+
+// Direction returns the normalized direction to move from a world position to follow the field. It returns false if
+// the goal cannot be reached from there.
+func (f *FlowField) Direction(from numerics.Vec2) (numerics.Vec2, bool) {
+	g := f.grid
+	c, ok := g.nearestOpen(g.CellAt(from))
+	if !ok {
+		return numerics.Vec2{}, false
+	}
+
+	next := f.next[g.index(c)]
+	if next == -1 {
+		return numerics.Vec2{}, false
+	}
+
+	// Already in the goal cell, head straight for the goal
+	target := f.goal
+	if int(next) != g.index(c) {
+		target = g.CellCenter(g.cell(int(next)))
+	}
+
+	d := target.Sub(from)
+	if d.IsZero() {
+		return numerics.ZeroVec2(), true
+	}
+	return d.Normalized(), true
+}
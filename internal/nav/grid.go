@@ -0,0 +1,231 @@
+package nav
+
+import (
+	"dungeon/internal/numerics"
+	"math"
+)
+
+// Cell is the integer coordinate of a cell in a Grid.
+type Cell struct {
+	X, Y int
+}
+
+// Add returns the cell offset by dx and dy.
+func (c Cell) Add(dx, dy int) Cell {
+	return Cell{c.X + dx, c.Y + dy}
+}
+
+// Grid is a uniform grid of walkable and blocked cells laid over an area of the world.
+type Grid struct {
+	// Origin is the world position of the top-left corner of cell (0, 0).
+	Origin numerics.Vec2
+
+	// CellSize is the width and height of a cell in pixels.
+	CellSize float64
+
+	Width  int
+	Height int
+
+	blocked []bool
+}
+
+func NewGrid(origin numerics.Vec2, cellSize float64, width, height int) *Grid {
+	return &Grid{
+		Origin:   origin,
+		CellSize: cellSize,
+		Width:    width,
+		Height:   height,
+		blocked:  make([]bool, width*height),
+	}
+}
+
+// InBounds reports whether the cell lies on the grid.
+func (g *Grid) InBounds(c Cell) bool {
+	return c.X >= 0 && c.Y >= 0 && c.X < g.Width && c.Y < g.Height
+}
+
+// Blocked reports whether the cell cannot be walked through. Cells off the grid are always blocked.
+func (g *Grid) Blocked(c Cell) bool {
+	if !g.InBounds(c) {
+		return true
+	}
+	return g.blocked[g.index(c)]
+}
+
+func (g *Grid) SetBlocked(c Cell, blocked bool) {
+	if g.InBounds(c) {
+		g.blocked[g.index(c)] = blocked
+	}
+}
+
+// BlockRect blocks every cell which overlaps the world-space rectangle.
+func (g *Grid) BlockRect(min, max numerics.Vec2) {
+	lo := g.CellAt(min)
+	// Cells are half-open, so a rectangle ending exactly on a cell boundary does not spill into the next cell
+	hi := g.CellAt(max.SubScalar(1e-9))
+
+	for y := lo.Y; y <= hi.Y; y++ {
+		for x := lo.X; x <= hi.X; x++ {
+			g.SetBlocked(Cell{x, y}, true)
+		}
+	}
+}
+
+// CellAt returns the cell containing the world position. The cell may be off the grid.
+func (g *Grid) CellAt(p numerics.Vec2) Cell {
+	local := p.Sub(g.Origin)
+	return Cell{
+		X: int(math.Floor(local.X() / g.CellSize)),
+		Y: int(math.Floor(local.Y() / g.CellSize)),
+	}
+}
+
+// CellCenter returns the world position of the center of the cell.
+func (g *Grid) CellCenter(c Cell) numerics.Vec2 {
+	return numerics.NewVec2(
+		g.Origin.X()+(float64(c.X)+0.5)*g.CellSize,
+		g.Origin.Y()+(float64(c.Y)+0.5)*g.CellSize,
+	)
+}
+
+func (g *Grid) index(c Cell) int {
+	return c.X + c.Y*g.Width
+}
+
+func (g *Grid) cell(i int) Cell {
+	return Cell{X: i % g.Width, Y: i / g.Width}
+}
+
+// neighbors lists the eight directions around a cell, orthogonal moves first.
+var neighbors = [8]Cell{
+	{1, 0}, {-1, 0}, {0, 1}, {0, -1},
+	{1, 1}, {1, -1}, {-1, 1}, {-1, -1},
+}
+
+// canStep reports whether a move from c by d is allowed. Diagonal moves may not cut a corner, both of the orthogonal
+// cells they pass between have to be open as well.
+func (g *Grid) canStep(c, d Cell) bool {
+	next := Cell{c.X + d.X, c.Y + d.Y}
+	if g.Blocked(next) {
+		return false
+	}
+
+	if d.X != 0 && d.Y != 0 {
+		if g.Blocked(Cell{c.X + d.X, c.Y}) || g.Blocked(Cell{c.X, c.Y + d.Y}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// stepCost is the cost of a move in cells, diagonals cost the square root of two.
+func stepCost(d Cell) float64 {
+	if d.X != 0 && d.Y != 0 {
+		return math.Sqrt2
+	}
+	return 1
+}
+
+// nearestOpen finds the closest unblocked cell to c by breadth-first search, for when a query starts or ends inside an
+// obstacle.
+func (g *Grid) nearestOpen(c Cell) (Cell, bool) {
+	if !g.Blocked(c) {
+		return c, true
+	}
+
+	// Clamp onto the grid first so a point just outside still finds the edge
+	c = Cell{min(max(c.X, 0), g.Width-1), min(max(c.Y, 0), g.Height-1)}
+	if !g.Blocked(c) {
+		return c, true
+	}
+
+	seen := make([]bool, len(g.blocked))
+	seen[g.index(c)] = true
+	queue := []Cell{c}
+
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		for _, d := range neighbors[:4] {
+			next := Cell{cur.X + d.X, cur.Y + d.Y}
+			if !g.InBounds(next) || seen[g.index(next)] {
+				continue
+			}
+
+			if !g.Blocked(next) {
+				return next, true
+			}
+
+			seen[g.index(next)] = true
+			queue = append(queue, next)
+		}
+	}
+
+	return Cell{}, false
+}
+
+// HasClearLine reports whether a straight line between two world positions only passes through open cells.
+func (g *Grid) HasClearLine(from, to numerics.Vec2) bool {
+	return g.clearLine(g.CellAt(from), g.CellAt(to))
+}
+
+// clearLine walks every cell the line between the centers of a and b passes through. When the line crosses exactly
+// through a corner both cells beside the corner must be open, the same rule diagonal steps follow.
+func (g *Grid) clearLine(a, b Cell) bool {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	nx, ny := abs(dx), abs(dy)
+	sx, sy := sign(dx), sign(dy)
+
+	c := a
+	if g.Blocked(c) {
+		return false
+	}
+
+	for ix, iy := 0, 0; ix < nx || iy < ny; {
+		// Compare which boundary the line reaches first: (0.5 + ix) / nx against (0.5 + iy) / ny
+		decision := (1+2*ix)*ny - (1+2*iy)*nx
+		switch {
+		case decision == 0:
+			// Passes exactly through a corner
+			if g.Blocked(Cell{c.X + sx, c.Y}) || g.Blocked(Cell{c.X, c.Y + sy}) {
+				return false
+			}
+			c.X += sx
+			c.Y += sy
+			ix++
+			iy++
+		case decision < 0:
+			c.X += sx
+			ix++
+		default:
+			c.Y += sy
+			iy++
+		}
+
+		if g.Blocked(c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func sign(v int) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	default:
+		return 0
+	}
+}
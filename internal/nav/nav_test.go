@@ -0,0 +1,285 @@
+package nav
+
+import (
+	"dungeon/internal/numerics"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// newTestGrid builds a w by h grid of cellSize cells with the cells blocked.
+func newTestGrid(w, h int, blocked ...Cell) *Grid {
+	grid := NewGrid(numerics.ZeroVec2(), cellSize, w, h)
+	for _, c := range blocked {
+		grid.SetBlocked(c, true)
+	}
+	return grid
+}
+
+// column returns the cells of column x from y0 up to but not including y1.
+func column(x, y0, y1 int) []Cell {
+	cells := make([]Cell, 0, y1-y0)
+	for y := y0; y < y1; y++ {
+		cells = append(cells, Cell{x, y})
+	}
+	return cells
+}
+
+func TestFindPathAroundWall(t *testing.T) {
+	// A wall down the middle with a gap at the bottom
+	grid := newTestGrid(10, 10, column(5, 0, 8)...)
+	from, to := grid.CellCenter(Cell{2, 2}), grid.CellCenter(Cell{8, 2}).AddScalar(3)
+
+	path, ok := grid.FindPath(from, to)
+	if !ok {
+		t.Fatal("no path around the wall")
+	}
+	if path[len(path)-1] != to {
+		t.Errorf("path ends at %v, want the goal %v", path[len(path)-1], to)
+	}
+
+	throughGap := false
+	prev := from
+	for _, p := range path {
+		if grid.Blocked(grid.CellAt(p)) {
+			t.Errorf("waypoint %v is in a blocked cell", p)
+		}
+		if !grid.HasClearLine(prev, p) {
+			t.Errorf("no clear line from %v to %v", prev, p)
+		}
+		throughGap = throughGap || grid.CellAt(p).Y >= 8
+		prev = p
+	}
+	if !throughGap {
+		t.Errorf("path %v doesn't go through the gap", path)
+	}
+}
+
+func TestFindPathNoPath(t *testing.T) {
+	grid := newTestGrid(10, 10, column(5, 0, 10)...)
+	if path, ok := grid.FindPath(grid.CellCenter(Cell{2, 2}), grid.CellCenter(Cell{8, 2})); ok {
+		t.Errorf("found path %v through a solid wall", path)
+	}
+}
+
+func TestFindPathDoesntCutCorners(t *testing.T) {
+	// The diagonal from (0, 0) to (1, 1) passes the corner of (1, 0)
+	grid := newTestGrid(3, 3, Cell{1, 0})
+	to := grid.CellCenter(Cell{1, 1})
+
+	path, ok := grid.FindPath(grid.CellCenter(Cell{0, 0}), to)
+	if !ok {
+		t.Fatal("no path")
+	}
+	if want := []numerics.Vec2{grid.CellCenter(Cell{0, 1}), to}; !slices.Equal(path, want) {
+		t.Errorf("path = %v, want %v", path, want)
+	}
+
+	// With both sides of the corner blocked the start is sealed in
+	grid.SetBlocked(Cell{0, 1}, true)
+	if path, ok := grid.FindPath(grid.CellCenter(Cell{0, 0}), to); ok {
+		t.Errorf("found path %v squeezing between two corners", path)
+	}
+}
+
+func TestSmooth(t *testing.T) {
+	tests := []struct {
+		name    string
+		blocked []Cell
+		cells   []Cell
+		want    []Cell
+	}{
+		{
+			name:  "too short to smooth",
+			cells: []Cell{{0, 0}, {1, 0}},
+			want:  []Cell{{0, 0}, {1, 0}},
+		},
+		{
+			name:  "straight line",
+			cells: []Cell{{0, 0}, {1, 0}, {2, 0}, {3, 0}},
+			want:  []Cell{{0, 0}, {3, 0}},
+		},
+		{
+			name:  "open diagonal",
+			cells: []Cell{{0, 0}, {1, 0}, {2, 0}, {2, 1}, {2, 2}},
+			want:  []Cell{{0, 0}, {2, 2}},
+		},
+		{
+			name:    "around a block",
+			blocked: []Cell{{1, 1}},
+			cells:   []Cell{{0, 0}, {1, 0}, {2, 0}, {2, 1}, {2, 2}},
+			want:    []Cell{{0, 0}, {2, 0}, {2, 2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grid := newTestGrid(5, 5, tt.blocked...)
+			if got := grid.Smooth(tt.cells); !slices.Equal(got, tt.want) {
+				t.Errorf("Smooth(%v) = %v, want %v", tt.cells, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlowFieldDirection(t *testing.T) {
+	grid := newTestGrid(5, 5)
+	goal := grid.CellCenter(Cell{4, 2})
+	field := grid.FlowField(goal)
+
+	if dir, ok := field.Direction(grid.CellCenter(Cell{0, 2})); !ok || dir != numerics.NewVec2(1, 0) {
+		t.Errorf("Direction along the row = %v, %v, want (1, 0)", dir, ok)
+	}
+
+	// In the goal cell it heads for the goal itself
+	if dir, ok := field.Direction(goal.Sub(numerics.NewVec2(0, 4))); !ok || dir != numerics.NewVec2(0, 1) {
+		t.Errorf("Direction in the goal cell = %v, %v, want (0, 1)", dir, ok)
+	}
+	if dir, ok := field.Direction(goal); !ok || !dir.IsZero() {
+		t.Errorf("Direction at the goal = %v, %v, want zero", dir, ok)
+	}
+}
+
+func TestFlowFieldAroundWall(t *testing.T) {
+	grid := newTestGrid(10, 10, column(5, 0, 8)...)
+	goal := grid.CellCenter(Cell{8, 2})
+	field := grid.FlowField(goal)
+
+	// Follow the field in small steps, it should reach the goal without entering the wall
+	p := grid.CellCenter(Cell{2, 2})
+	for step := 0; step < 1000 && p.Sub(goal).Length() > cellSize/4; step++ {
+		dir, ok := field.Direction(p)
+		if !ok {
+			t.Fatalf("no direction at %v", p)
+		}
+
+		p = p.Add(dir.MulScalar(cellSize / 4))
+		if grid.Blocked(grid.CellAt(p)) {
+			t.Fatalf("field led into the wall at %v", p)
+		}
+	}
+
+	if p.Sub(goal).Length() > cellSize/4 {
+		t.Errorf("following the field ended at %v, not the goal %v", p, goal)
+	}
+}
+
+func TestFlowFieldUnreachable(t *testing.T) {
+	grid := newTestGrid(10, 10, column(5, 0, 10)...)
+	field := grid.FlowField(grid.CellCenter(Cell{8, 2}))
+
+	if dir, ok := field.Direction(grid.CellCenter(Cell{2, 2})); ok {
+		t.Errorf("Direction from behind a solid wall = %v, want none", dir)
+	}
+}
+
+// The benchmarks run over a grid the size of the largest room the level generator makes, with pillars laid out in a
+// regular grid so that paths have to weave around them.
+const (
+	// roomSize is the width and height of the room, cellSize its tile size
+	roomSize = 1488
+	cellSize = 16
+
+	// pillarSize is the width of a pillar, pillarSpacing the distance between them and agentRadius how far characters
+	// keep from them
+	pillarSize    = 3 * cellSize
+	pillarSpacing = 4 * pillarSize
+	agentRadius   = 12
+
+	// chasers is how many enemies the many-agent benchmarks steer at once
+	chasers = 50
+)
+
+// pillarGrid builds the benchmark grid, blocked the way a room blocks its walls and obstacles.
+func pillarGrid() *Grid {
+	cells := roomSize / cellSize
+	grid := NewGrid(numerics.ZeroVec2(), cellSize, cells, cells)
+
+	end := numerics.NewVec2(roomSize, roomSize)
+	grid.BlockRect(numerics.ZeroVec2(), numerics.NewVec2(roomSize, agentRadius))
+	grid.BlockRect(numerics.NewVec2(0, roomSize-agentRadius), end)
+	grid.BlockRect(numerics.ZeroVec2(), numerics.NewVec2(agentRadius, roomSize))
+	grid.BlockRect(numerics.NewVec2(roomSize-agentRadius, 0), end)
+
+	for y := float64(pillarSpacing); y < roomSize-pillarSpacing; y += pillarSpacing {
+		for x := float64(pillarSpacing); x < roomSize-pillarSpacing; x += pillarSpacing {
+			grid.BlockRect(
+				numerics.NewVec2(x-agentRadius, y-agentRadius),
+				numerics.NewVec2(x+pillarSize+agentRadius, y+pillarSize+agentRadius),
+			)
+		}
+	}
+	return grid
+}
+
+// corners returns a start and goal two cells in from opposite corners of the grid.
+func corners(grid *Grid) (numerics.Vec2, numerics.Vec2) {
+	start := grid.CellCenter(Cell{2, 2})
+	goal := grid.CellCenter(Cell{grid.Width - 3, grid.Height - 3})
+	return start, goal
+}
+
+// scattered returns chasers positions spread over the grid, the same every run.
+func scattered(grid *Grid) []numerics.Vec2 {
+	rng := rand.New(rand.NewSource(1))
+	positions := make([]numerics.Vec2, chasers)
+	for i := range positions {
+		positions[i] = grid.CellCenter(Cell{rng.Intn(grid.Width), rng.Intn(grid.Height)})
+	}
+	return positions
+}
+
+func BenchmarkBuildGrid(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		pillarGrid()
+	}
+}
+
+func BenchmarkFindPathCornerToCorner(b *testing.B) {
+	grid := pillarGrid()
+	start, goal := corners(grid)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, ok := grid.FindPath(start, goal); !ok {
+			b.Fatal("no path")
+		}
+	}
+}
+
+func BenchmarkBuildFlowField(b *testing.B) {
+	grid := pillarGrid()
+	_, goal := corners(grid)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		grid.FlowField(goal)
+	}
+}
+
+func BenchmarkFlowFieldSteerChasers(b *testing.B) {
+	grid := pillarGrid()
+	_, goal := corners(grid)
+	field := grid.FlowField(goal)
+	positions := scattered(grid)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, p := range positions {
+			field.Direction(p)
+		}
+	}
+}
+
+func BenchmarkFindPathChasers(b *testing.B) {
+	grid := pillarGrid()
+	_, goal := corners(grid)
+	positions := scattered(grid)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, p := range positions {
+			grid.FindPath(p, goal)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package replay
+
+import (
+	"bufio"
+	"dungeon/internal/input"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// A replay file is a short header followed by a stream of tagged records:
+//
+//	header:   magic "DGRP" | version u8 | seed varint | checksum interval uvarint
+//	frames:   'F' | repeat uvarint | actions uvarint | cursor dx varint | cursor dy varint
+//	checksum: 'C' | frame uvarint | sum u32
+//
+// Frame records are run-length encoded: consecutive identical input states are written once with a repeat count, and
+// the cursor is stored as a delta from the previous record, so an idle player costs a handful of bytes per second.
+const (
+	magic   = "DGRP"
+	version = 1
+
+	tagFrame    byte = 'F'
+	tagChecksum byte = 'C'
+)
+
+// DefaultChecksumInterval is how many frames pass between state checksums.
+const DefaultChecksumInterval = 60
+
+var ErrBadHeader = errors.New("replay: not a replay file")
+
+// DivergenceError is returned during playback when the simulated state does not match the recording.
+type DivergenceError struct {
+	Frame    uint64
+	Expected uint32
+	Actual   uint32
+}
+
+func (e *DivergenceError) Error() string {
+	return fmt.Sprintf(
+		"replay: diverged at frame %d: expected checksum %08x, got %08x",
+		e.Frame, e.Expected, e.Actual,
+	)
+}
+
+// Header is the metadata stored at the top of every replay.
+type Header struct {
+	// Seed is the level seed the recording was started from.
+	Seed int64
+
+	// ChecksumInterval is how many frames pass between state checksums.
+	ChecksumInterval uint64
+}
+
+// Recorder is an input.Source which forwards input from another source and writes each state to a replay file.
+type Recorder struct {
+	Header
+
+	src input.Source
+	w   *bufio.Writer
+
+	last    input.State
+	pending uint64
+
+	// cursor holds the last cursor position written, deltas are taken against it.
+	cursorX, cursorY int
+}
+
+func NewRecorder(w io.Writer, header Header, src input.Source) (*Recorder, error) {
+	if header.ChecksumInterval == 0 {
+		header.ChecksumInterval = DefaultChecksumInterval
+	}
+
+	r := &Recorder{
+		Header: header,
+		src:    src,
+		w:      bufio.NewWriter(w),
+	}
+
+	buf := []byte(magic)
+	buf = append(buf, version)
+	buf = binary.AppendVarint(buf, header.Seed)
+	buf = binary.AppendUvarint(buf, header.ChecksumInterval)
+	if _, err := r.w.Write(buf); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *Recorder) Next() (input.State, error) {
+	s, err := r.src.Next()
+	if err != nil {
+		return s, err
+	}
+
+	if r.pending > 0 && s != r.last {
+		if err := r.flush(); err != nil {
+			return s, err
+		}
+	}
+
+	r.last = s
+	r.pending++
+	return s, nil
+}
+
+// Checksum writes the state checksum for the given frame.
+func (r *Recorder) Checksum(frame uint64, sum uint32) error {
+	if err := r.flush(); err != nil {
+		return err
+	}
+
+	buf := []byte{tagChecksum}
+	buf = binary.AppendUvarint(buf, frame)
+	buf = binary.LittleEndian.AppendUint32(buf, sum)
+	_, err := r.w.Write(buf)
+	return err
+}
+
+// Close writes any buffered frames. It does not close the underlying writer.
+func (r *Recorder) Close() error {
+	if err := r.flush(); err != nil {
+		return err
+	}
+	return r.w.Flush()
+}
+
+func (r *Recorder) flush() error {
+	if r.pending == 0 {
+		return nil
+	}
+
+	buf := []byte{tagFrame}
+	buf = binary.AppendUvarint(buf, r.pending)
+	buf = binary.AppendUvarint(buf, uint64(r.last.Actions))
+	buf = binary.AppendVarint(buf, int64(r.last.CursorX-r.cursorX))
+	buf = binary.AppendVarint(buf, int64(r.last.CursorY-r.cursorY))
+
+	r.cursorX, r.cursorY = r.last.CursorX, r.last.CursorY
+	r.pending = 0
+
+	_, err := r.w.Write(buf)
+	return err
+}
+
+// Player is an input.Source which reads input states back from a replay file.
+type Player struct {
+	Header
+
+	r *bufio.Reader
+
+	current   input.State
+	remaining uint64
+
+	// checksums holds the recorded checksums which have been read ahead of the simulation.
+	checksums map[uint64]uint32
+}
+
+func NewPlayer(r io.Reader) (*Player, error) {
+	p := &Player{
+		r:         bufio.NewReader(r),
+		checksums: make(map[uint64]uint32),
+	}
+
+	head := make([]byte, len(magic)+1)
+	if _, err := io.ReadFull(p.r, head); err != nil {
+		return nil, ErrBadHeader
+	}
+
+	if string(head[:len(magic)]) != magic {
+		return nil, ErrBadHeader
+	}
+
+	if head[len(magic)] != version {
+		return nil, fmt.Errorf("replay: unsupported version %d", head[len(magic)])
+	}
+
+	var err error
+	if p.Seed, err = binary.ReadVarint(p.r); err != nil {
+		return nil, ErrBadHeader
+	}
+
+	if p.ChecksumInterval, err = binary.ReadUvarint(p.r); err != nil {
+		return nil, ErrBadHeader
+	}
+
+	return p, nil
+}
+
+// Next returns the next recorded state, or io.EOF once the recording is exhausted.
+func (p *Player) Next() (input.State, error) {
+	for p.remaining == 0 {
+		if err := p.readRecord(); err != nil {
+			return input.State{}, err
+		}
+	}
+
+	p.remaining--
+	return p.current, nil
+}
+
+// Checksum compares the state checksum for the given frame against the recording.
+func (p *Player) Checksum(frame uint64, sum uint32) error {
+	// Checksums are written after the frames they cover, so read ahead until we have seen this one.
+	for {
+		if expected, ok := p.checksums[frame]; ok {
+			delete(p.checksums, frame)
+			if expected != sum {
+				return &DivergenceError{Frame: frame, Expected: expected, Actual: sum}
+			}
+			return nil
+		}
+
+		if p.remaining > 0 {
+			// The next record is a frame that has not been consumed, so this checksum was never recorded.
+			return nil
+		}
+
+		if err := p.readRecord(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func (p *Player) readRecord() error {
+	tag, err := p.r.ReadByte()
+	if err != nil {
+		return err
+	}
+
+	switch tag {
+	case tagFrame:
+		repeat, err := binary.ReadUvarint(p.r)
+		if err != nil {
+			return unexpected(err)
+		}
+
+		actions, err := binary.ReadUvarint(p.r)
+		if err != nil {
+			return unexpected(err)
+		}
+
+		dx, err := binary.ReadVarint(p.r)
+		if err != nil {
+			return unexpected(err)
+		}
+
+		dy, err := binary.ReadVarint(p.r)
+		if err != nil {
+			return unexpected(err)
+		}
+
+		p.current = input.State{
+			Actions: input.Action(actions),
+			CursorX: p.current.CursorX + int(dx),
+			CursorY: p.current.CursorY + int(dy),
+		}
+		p.remaining = repeat
+	case tagChecksum:
+		frame, err := binary.ReadUvarint(p.r)
+		if err != nil {
+			return unexpected(err)
+		}
+
+		var sum [4]byte
+		if _, err := io.ReadFull(p.r, sum[:]); err != nil {
+			return unexpected(err)
+		}
+
+		p.checksums[frame] = binary.LittleEndian.Uint32(sum[:])
+	default:
+		return fmt.Errorf("replay: unknown record tag %q", tag)
+	}
+
+	return nil
+}
+
+// unexpected converts an EOF in the middle of a record into io.ErrUnexpectedEOF so a truncated file is not mistaken
+// for the end of the recording.
+func unexpected(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
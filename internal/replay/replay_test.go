@@ -0,0 +1,165 @@
+package replay
+
+import (
+	"bytes"
+	"dungeon/internal/input"
+	"errors"
+	"hash/fnv"
+	"io"
+	"testing"
+)
+
+// scripted is an input source playing back a fixed list of states.
+type scripted []input.State
+
+func (s *scripted) Next() (input.State, error) {
+	if len(*s) == 0 {
+		return input.State{}, io.EOF
+	}
+	state := (*s)[0]
+	*s = (*s)[1:]
+	return state, nil
+}
+
+// script builds frames of input with runs of held keys and a moving cursor, so both the run length encoding and the
+// cursor deltas are exercised.
+func script(frames int) scripted {
+	states := make(scripted, frames)
+	for i := range states {
+		states[i] = input.State{CursorX: 100 + i/7, CursorY: 50 - i/11}
+		if i/20%2 == 0 {
+			states[i].Actions = input.MoveRight
+		}
+		if i%45 == 0 {
+			states[i].Actions |= input.Fire
+		}
+	}
+	return states
+}
+
+// checksummer is the part of the game's Checksummer the simulation needs.
+type checksummer interface {
+	Checksum(frame uint64, sum uint32) error
+}
+
+// simulate stands in for the game loop: it folds every input state into its state, sending a checksum to the
+// checksummer every interval frames, until the source runs out.
+func simulate(t *testing.T, src input.Source, c checksummer, interval uint64) error {
+	t.Helper()
+
+	state := fnv.New32a()
+	for frame := uint64(1); ; frame++ {
+		s, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		state.Write([]byte{byte(s.Actions), byte(s.Actions >> 8), byte(s.CursorX), byte(s.CursorY)})
+		if frame%interval == 0 {
+			if err := c.Checksum(frame, state.Sum32()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// record runs the simulation over the states, returning the replay file.
+func record(t *testing.T, states scripted) []byte {
+	t.Helper()
+
+	var file bytes.Buffer
+	rec, err := NewRecorder(&file, Header{Seed: -42, ChecksumInterval: 10}, &states)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := simulate(t, rec, rec, rec.ChecksumInterval); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return file.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	states := script(300)
+	file := record(t, append(scripted(nil), states...))
+
+	player, err := NewPlayer(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if player.Seed != -42 || player.ChecksumInterval != 10 {
+		t.Fatalf("header = %+v, want seed -42 and interval 10", player.Header)
+	}
+
+	// Every state comes back in order
+	for i, want := range states {
+		got, err := player.Next()
+		if err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		if got != want {
+			t.Fatalf("frame %d: got %+v, want %+v", i, got, want)
+		}
+	}
+	if _, err := player.Next(); !errors.Is(err, io.EOF) {
+		t.Fatalf("after the last frame got %v, want io.EOF", err)
+	}
+
+	// Playing the recording back through the same simulation matches every checksum
+	player, err = NewPlayer(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := simulate(t, player, player, player.ChecksumInterval); err != nil {
+		t.Fatalf("replay diverged: %v", err)
+	}
+}
+
+func TestDivergence(t *testing.T) {
+	file := record(t, script(300))
+
+	player, err := NewPlayer(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A simulation which doesn't react to input the way the recorded one did, here one which sees a key the
+	// recording never pressed from frame 125 on
+	changed := &changedSource{src: player, from: 125}
+	err = simulate(t, changed, player, player.ChecksumInterval)
+
+	var divergence *DivergenceError
+	if !errors.As(err, &divergence) {
+		t.Fatalf("got %v, want a DivergenceError", err)
+	}
+	if divergence.Frame != 130 {
+		t.Errorf("diverged at frame %d, want 130", divergence.Frame)
+	}
+}
+
+// changedSource adds a held key to every state from a frame on.
+type changedSource struct {
+	src   input.Source
+	frame int
+	from  int
+}
+
+func (c *changedSource) Next() (input.State, error) {
+	s, err := c.src.Next()
+	c.frame++
+	if c.frame >= c.from {
+		s.Actions |= input.Sprint
+	}
+	return s, err
+}
+
+func TestBadHeader(t *testing.T) {
+	if _, err := NewPlayer(bytes.NewReader([]byte("nope"))); !errors.Is(err, ErrBadHeader) {
+		t.Fatalf("got %v, want ErrBadHeader", err)
+	}
+}
@@ -0,0 +1,244 @@
+// Package stats holds a character's numeric stats and the modifiers stacked on top of them by items, buffs and
+// level-ups.
+package stats
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Stat is a single numeric attribute of a character.
+type Stat int
+
+const (
+	// MoveSpeed is the walking top speed in pixels per tick.
+	MoveSpeed Stat = iota
+
+	MaxHealth
+	MaxMana
+
+	// ManaRegen is the mana regained per second.
+	ManaRegen
+
+	// FireRate is the number of basic attacks per second.
+	FireRate
+
+	// Damage is the damage of a basic attack.
+	Damage
+
+	// CritChance is the chance, from 0 to 1, of a basic attack being a critical hit.
+	CritChance
+
+	// CritMultiplier scales the damage of a critical hit.
+	CritMultiplier
+
+	// statCount is the number of stats, it must stay last
+	statCount
+)
+
+var statNames = [statCount]string{
+	MoveSpeed:      "move_speed",
+	MaxHealth:      "max_health",
+	MaxMana:        "max_mana",
+	ManaRegen:      "mana_regen",
+	FireRate:       "fire_rate",
+	Damage:         "damage",
+	CritChance:     "crit_chance",
+	CritMultiplier: "crit_multiplier",
+}
+
+// All returns every stat in order.
+func All() []Stat {
+	all := make([]Stat, statCount)
+	for i := range all {
+		all[i] = Stat(i)
+	}
+	return all
+}
+
+func (s Stat) String() string {
+	if s < 0 || s >= statCount {
+		return "unknown"
+	}
+	return statNames[s]
+}
+
+func (s Stat) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Stat) UnmarshalText(text []byte) error {
+	for st := Stat(0); st < statCount; st++ {
+		if strings.EqualFold(st.String(), string(text)) {
+			*s = st
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown stat %q", text)
+}
+
+// Kind is how a modifier changes a stat. Modifiers are applied kind by kind in the order listed here, whatever order
+// they were added in.
+type Kind int
+
+const (
+	// Flat modifiers are added to the base value.
+	Flat Kind = iota
+
+	// Percent modifiers are summed and then scale the flat total, so two +10% modifiers make +20%.
+	Percent
+
+	// Multiply modifiers each scale the result on their own, so two x1.1 modifiers make x1.21.
+	Multiply
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Flat:
+		return "Flat"
+	case Percent:
+		return "Percent"
+	case Multiply:
+		return "Multiply"
+	default:
+		return "Unknown"
+	}
+}
+
+func (k Kind) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(k.String())), nil
+}
+
+func (k *Kind) UnmarshalText(text []byte) error {
+	for mk := Flat; mk <= Multiply; mk++ {
+		if strings.EqualFold(mk.String(), string(text)) {
+			*k = mk
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown modifier kind %q", text)
+}
+
+// Modifier changes one stat. Percent values are fractions, 0.1 is +10%.
+type Modifier struct {
+	Stat  Stat    `json:"stat"`
+	Kind  Kind    `json:"kind"`
+	Value float64 `json:"value"`
+
+	// Source is whatever granted the modifier, such as an equipment slot or a buff. Every modifier from a source is
+	// removed together when the source goes away.
+	Source string `json:"-"`
+}
+
+func (m Modifier) String() string {
+	switch m.Kind {
+	case Percent:
+		return fmt.Sprintf("%s %+.0f%%", m.Stat, m.Value*100)
+	case Multiply:
+		return fmt.Sprintf("%s x%.2f", m.Stat, m.Value)
+	default:
+		return fmt.Sprintf("%s %+g", m.Stat, m.Value)
+	}
+}
+
+// Sheet is a character's stats: a base value for each stat and the modifiers on top of it.
+type Sheet struct {
+	base      [statCount]float64
+	modifiers []Modifier
+
+	// values are the modified stats, recalculated whenever the base or the modifiers change
+	values [statCount]float64
+}
+
+// NewSheet creates a sheet with the given base values, any stat left out has a base of 0.
+func NewSheet(base map[Stat]float64) *Sheet {
+	s := &Sheet{}
+	for stat, v := range base {
+		if stat >= 0 && stat < statCount {
+			s.base[stat] = v
+		}
+	}
+	s.recalculate()
+	return s
+}
+
+// Get returns the value of the stat with every modifier applied.
+func (s *Sheet) Get(stat Stat) float64 {
+	if stat < 0 || stat >= statCount {
+		return 0
+	}
+	return s.values[stat]
+}
+
+// Base returns the value of the stat before modifiers.
+func (s *Sheet) Base(stat Stat) float64 {
+	if stat < 0 || stat >= statCount {
+		return 0
+	}
+	return s.base[stat]
+}
+
+// SetBase changes the value of the stat before modifiers.
+func (s *Sheet) SetBase(stat Stat, v float64) {
+	if stat < 0 || stat >= statCount {
+		return
+	}
+	s.base[stat] = v
+	s.recalculate()
+}
+
+// Add adds the modifiers, all from the given source.
+func (s *Sheet) Add(source string, modifiers ...Modifier) {
+	for _, m := range modifiers {
+		m.Source = source
+		s.modifiers = append(s.modifiers, m)
+	}
+	s.recalculate()
+}
+
+// Remove removes every modifier from the source, returning how many there were.
+func (s *Sheet) Remove(source string) int {
+	before := len(s.modifiers)
+	s.modifiers = slices.DeleteFunc(s.modifiers, func(m Modifier) bool { return m.Source == source })
+	s.recalculate()
+	return before - len(s.modifiers)
+}
+
+// Replace swaps every modifier from the source for the given ones.
+func (s *Sheet) Replace(source string, modifiers ...Modifier) {
+	s.Remove(source)
+	s.Add(source, modifiers...)
+}
+
+// Modifiers returns the modifiers on the sheet in the order they were added.
+func (s *Sheet) Modifiers() []Modifier {
+	return slices.Clone(s.modifiers)
+}
+
+// recalculate applies the modifiers to the base values: flat first, then the summed percentages, then each multiplier.
+func (s *Sheet) recalculate() {
+	var flat, percent, multiply [statCount]float64
+	for i := range multiply {
+		multiply[i] = 1
+	}
+
+	for _, m := range s.modifiers {
+		if m.Stat < 0 || m.Stat >= statCount {
+			continue
+		}
+
+		switch m.Kind {
+		case Flat:
+			flat[m.Stat] += m.Value
+		case Percent:
+			percent[m.Stat] += m.Value
+		case Multiply:
+			multiply[m.Stat] *= m.Value
+		}
+	}
+
+	for i := range s.values {
+		s.values[i] = max((s.base[i]+flat[i])*(1+percent[i])*multiply[i], 0)
+	}
+}
@@ -0,0 +1,118 @@
+package stats
+
+import (
+	"testing"
+)
+
+func TestSheetModifiers(t *testing.T) {
+	tests := []struct {
+		name      string
+		base      float64
+		modifiers map[string][]Modifier
+		remove    []string
+		want      float64
+	}{
+		{
+			name: "base only",
+			base: 10,
+			want: 10,
+		},
+		{
+			name: "flat before percent",
+			base: 10,
+			modifiers: map[string][]Modifier{
+				"ring": {{Stat: Damage, Kind: Percent, Value: 0.5}},
+				"buff": {{Stat: Damage, Kind: Flat, Value: 10}},
+			},
+			want: 30,
+		},
+		{
+			name: "percentages add up",
+			base: 10,
+			modifiers: map[string][]Modifier{
+				"ring":   {{Stat: Damage, Kind: Percent, Value: 0.1}},
+				"amulet": {{Stat: Damage, Kind: Percent, Value: 0.1}},
+			},
+			want: 12,
+		},
+		{
+			name: "multipliers compound after percent",
+			base: 10,
+			modifiers: map[string][]Modifier{
+				"ring":   {{Stat: Damage, Kind: Multiply, Value: 2}},
+				"amulet": {{Stat: Damage, Kind: Multiply, Value: 1.5}},
+				"buff":   {{Stat: Damage, Kind: Percent, Value: 1}},
+				"sword":  {{Stat: Damage, Kind: Flat, Value: 5}},
+			},
+			want: 90,
+		},
+		{
+			name: "other stats are untouched",
+			base: 10,
+			modifiers: map[string][]Modifier{
+				"boots": {{Stat: MoveSpeed, Kind: Flat, Value: 5}},
+			},
+			want: 10,
+		},
+		{
+			name: "never below zero",
+			base: 10,
+			modifiers: map[string][]Modifier{
+				"curse": {{Stat: Damage, Kind: Flat, Value: -20}},
+			},
+			want: 0,
+		},
+		{
+			name: "remove takes every modifier from the source",
+			base: 10,
+			modifiers: map[string][]Modifier{
+				"ring": {{Stat: Damage, Kind: Flat, Value: 5}, {Stat: Damage, Kind: Multiply, Value: 2}},
+				"buff": {{Stat: Damage, Kind: Flat, Value: 5}},
+			},
+			remove: []string{"ring"},
+			want:   15,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSheet(map[Stat]float64{Damage: tt.base})
+			for source, modifiers := range tt.modifiers {
+				s.Add(source, modifiers...)
+			}
+			for _, source := range tt.remove {
+				s.Remove(source)
+			}
+
+			if got := s.Get(Damage); got != tt.want {
+				t.Errorf("Get(Damage) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSheetReplace(t *testing.T) {
+	s := NewSheet(map[Stat]float64{Damage: 10})
+	s.Add("weapon", Modifier{Stat: Damage, Kind: Flat, Value: 5})
+	s.Add("buff", Modifier{Stat: Damage, Kind: Flat, Value: 1})
+
+	s.Replace("weapon", Modifier{Stat: Damage, Kind: Flat, Value: 20})
+	if got := s.Get(Damage); got != 31 {
+		t.Errorf("after Replace, Get(Damage) = %v, want 31", got)
+	}
+
+	if n := s.Remove("weapon"); n != 1 {
+		t.Errorf("Remove returned %d, want 1", n)
+	}
+	if n := s.Remove("weapon"); n != 0 {
+		t.Errorf("second Remove returned %d, want 0", n)
+	}
+
+	modifiers := s.Modifiers()
+	if len(modifiers) != 1 || modifiers[0].Source != "buff" {
+		t.Errorf("Modifiers() = %v, want only the buff", modifiers)
+	}
+	if got := s.Get(Damage); got != 11 {
+		t.Errorf("after Remove, Get(Damage) = %v, want 11", got)
+	}
+}